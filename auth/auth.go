@@ -0,0 +1,202 @@
+// Package auth autentica las llamadas gRPC con tokens bearer (API keys de un
+// archivo estático o JWT firmados con HMAC verificados localmente), asocia
+// cada token a un tenant y a roles, y autoriza cada método según una política.
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Roles predefinidos; admin incluye a todos los demás
+const (
+	RoleSubmit   = "submit"   // Enviar trabajos al balanceador
+	RoleBalancer = "balancer" // Reenviar trabajos a los servidores (solo el balanceador)
+	RoleAdmin    = "admin"    // Administrar fallas, drenado y configuración
+)
+
+// Identidad autenticada de una llamada
+type Principal struct {
+	Subject string   `json:"subject"`
+	Tenant  string   `json:"tenant,omitempty"` // Vacío = el que indique la solicitud
+	Roles   []string `json:"roles"`
+}
+
+// Indica si el principal tiene el rol (o es admin)
+func (p *Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role || r == RoleAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// Archivo de API keys: token -> identidad
+type KeysFile struct {
+	Keys map[string]Principal `json:"keys"`
+}
+
+// Lee las API keys desde un archivo JSON
+func ReadKeysFromFile(filename string) (map[string]Principal, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error al leer el archivo de API keys: %v", err)
+	}
+	var file KeysFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("error al interpretar el archivo de API keys: %v", err)
+	}
+	for key, p := range file.Keys {
+		if p.Subject == "" {
+			p.Subject = "key-" + key[:min(4, len(key))]
+			file.Keys[key] = p
+		}
+	}
+	return file.Keys, nil
+}
+
+// Lee el secreto compartido para firmar y verificar JWT
+func ReadSecretFromFile(filename string) ([]byte, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error al leer el secreto JWT: %v", err)
+	}
+	secret := []byte(strings.TrimSpace(string(content)))
+	if len(secret) < 32 {
+		return nil, fmt.Errorf("el secreto JWT debe tener al menos 32 bytes")
+	}
+	return secret, nil
+}
+
+// Verifica tokens contra las API keys y/o el secreto JWT
+type Authenticator struct {
+	keys   map[string]Principal
+	secret []byte
+}
+
+// Crea un autenticador; keysFile y secretFile son opcionales pero al menos uno es necesario
+func NewAuthenticator(keysFile, secretFile string) (*Authenticator, error) {
+	a := &Authenticator{}
+	var err error
+	if keysFile != "" {
+		if a.keys, err = ReadKeysFromFile(keysFile); err != nil {
+			return nil, err
+		}
+	}
+	if secretFile != "" {
+		if a.secret, err = ReadSecretFromFile(secretFile); err != nil {
+			return nil, err
+		}
+	}
+	if a.keys == nil && a.secret == nil {
+		return nil, fmt.Errorf("se necesita un archivo de API keys o un secreto JWT")
+	}
+	return a, nil
+}
+
+// Identidad asociada al token
+func (a *Authenticator) Authenticate(token string) (*Principal, error) {
+	if token == "" {
+		return nil, fmt.Errorf("falta el token")
+	}
+	if a.secret != nil && strings.Count(token, ".") == 2 {
+		return VerifyJWT(token, a.secret, time.Now())
+	}
+	for key, p := range a.keys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(token)) == 1 {
+			p := p
+			return &p, nil
+		}
+	}
+	return nil, fmt.Errorf("token inválido")
+}
+
+// Claims reconocidos en los JWT
+type Claims struct {
+	Subject   string   `json:"sub"`
+	Tenant    string   `json:"tenant,omitempty"`
+	Roles     []string `json:"roles"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	ExpiresAt int64    `json:"exp,omitempty"`
+}
+
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+func sign(data string, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(data))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Firma un JWT HS256 con los claims dados
+func SignJWT(claims Claims, secret []byte) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	data := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return data + "." + sign(data, secret), nil
+}
+
+// Verifica la firma y la vigencia de un JWT HS256
+func VerifyJWT(token string, secret []byte, now time.Time) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("JWT mal formado")
+	}
+	header, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("encabezado JWT inválido: %v", err)
+	}
+	var h struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(header, &h); err != nil || h.Alg != "HS256" {
+		return nil, fmt.Errorf("algoritmo JWT no admitido: %q", h.Alg)
+	}
+	expected := sign(parts[0]+"."+parts[1], secret)
+	if !hmac.Equal([]byte(expected), []byte(parts[2])) {
+		return nil, fmt.Errorf("firma JWT inválida")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("contenido JWT inválido: %v", err)
+	}
+	var c Claims
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return nil, fmt.Errorf("claims JWT inválidos: %v", err)
+	}
+	if c.ExpiresAt != 0 && now.Unix() >= c.ExpiresAt {
+		return nil, fmt.Errorf("JWT vencido")
+	}
+	if c.NotBefore != 0 && now.Unix() < c.NotBefore {
+		return nil, fmt.Errorf("JWT todavía no válido")
+	}
+	if c.Subject == "" {
+		return nil, fmt.Errorf("JWT sin sujeto")
+	}
+	return &Principal{Subject: c.Subject, Tenant: c.Tenant, Roles: c.Roles}, nil
+}
+
+type principalKey struct{}
+
+// Identidad autenticada de la llamada (nil si no hay autenticación)
+func FromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
+
+func newContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
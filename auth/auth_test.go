@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testSecret = []byte("secreto-de-prueba-de-32-bytes-o-mas")
+
+func TestVerifyJWT(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	signed := func(c Claims) string {
+		token, err := SignJWT(c, testSecret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	valid := signed(Claims{Subject: "cliente", Tenant: "a", Roles: []string{RoleSubmit}})
+	parts := strings.Split(valid, ".")
+	// Mismo contenido y firma con otro algoritmo en el encabezado
+	noneHeader := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`))
+	hs512Header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS512","typ":"JWT"}`))
+
+	tests := []struct {
+		name    string
+		token   string
+		secret  []byte
+		wantErr string
+		want    *Principal
+	}{
+		{"válido", valid, testSecret, "", &Principal{Subject: "cliente", Tenant: "a", Roles: []string{RoleSubmit}}},
+		{"vigente", signed(Claims{Subject: "c", NotBefore: now.Unix() - 1, ExpiresAt: now.Unix() + 1}), testSecret, "", &Principal{Subject: "c"}},
+		{"firma de otro secreto", valid, []byte("otro-secreto-de-prueba-de-32-bytes!!"), "firma JWT inválida", nil},
+		{"firma alterada", parts[0] + "." + parts[1] + "." + parts[2][:len(parts[2])-2] + "AA", testSecret, "firma JWT inválida", nil},
+		{"alg none", noneHeader + "." + parts[1] + ".", testSecret, "algoritmo JWT no admitido", nil},
+		{"alg HS512", hs512Header + "." + parts[1] + "." + parts[2], testSecret, "algoritmo JWT no admitido", nil},
+		{"vencido", signed(Claims{Subject: "c", ExpiresAt: now.Unix()}), testSecret, "JWT vencido", nil},
+		{"todavía no válido", signed(Claims{Subject: "c", NotBefore: now.Unix() + 60}), testSecret, "JWT todavía no válido", nil},
+		{"sin sujeto", signed(Claims{Roles: []string{RoleAdmin}}), testSecret, "JWT sin sujeto", nil},
+		{"dos partes", parts[0] + "." + parts[1], testSecret, "JWT mal formado", nil},
+		{"encabezado no base64", "%%%." + parts[1] + "." + parts[2], testSecret, "encabezado JWT inválido", nil},
+		{"encabezado no JSON", base64.RawURLEncoding.EncodeToString([]byte("hola")) + "." + parts[1] + "." + parts[2], testSecret, "algoritmo JWT no admitido", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := VerifyJWT(tt.token, tt.secret, now)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, se esperaba %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Subject != tt.want.Subject || got.Tenant != tt.want.Tenant || strings.Join(got.Roles, ",") != strings.Join(tt.want.Roles, ",") {
+				t.Errorf("principal %+v, se esperaba %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	jwt, err := SignJWT(Claims{Subject: "jwt-cliente", Roles: []string{RoleSubmit}}, testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := map[string]Principal{"clave-1": {Subject: "key-clav", Roles: []string{RoleAdmin}}}
+
+	tests := []struct {
+		name  string
+		a     *Authenticator
+		token string
+		want  string // Sujeto esperado ("" = error)
+	}{
+		{"API key", &Authenticator{keys: keys, secret: testSecret}, "clave-1", "key-clav"},
+		{"JWT", &Authenticator{keys: keys, secret: testSecret}, jwt, "jwt-cliente"},
+		{"API key desconocida", &Authenticator{keys: keys, secret: testSecret}, "clave-2", ""},
+		{"sin token", &Authenticator{keys: keys, secret: testSecret}, "", ""},
+		{"JWT sin secreto configurado", &Authenticator{keys: keys}, jwt, ""},
+		{"API key sin archivo de keys", &Authenticator{secret: testSecret}, "clave-1", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := tt.a.Authenticate(tt.token)
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("se autenticó como %s", p.Subject)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.Subject != tt.want {
+				t.Errorf("sujeto %s, se esperaba %s", p.Subject, tt.want)
+			}
+		})
+	}
+
+	// El principal devuelto es una copia: cambiarlo no altera la tabla de keys
+	a := &Authenticator{keys: keys}
+	p, _ := a.Authenticate("clave-1")
+	p.Subject = "otro"
+	if again, _ := a.Authenticate("clave-1"); again.Subject != "key-clav" {
+		t.Errorf("la tabla de keys cambió: %s", again.Subject)
+	}
+}
+
+func TestHasRole(t *testing.T) {
+	tests := []struct {
+		roles []string
+		role  string
+		want  bool
+	}{
+		{[]string{RoleSubmit}, RoleSubmit, true},
+		{[]string{RoleSubmit}, RoleBalancer, false},
+		{[]string{RoleAdmin}, RoleBalancer, true},
+		{nil, RoleSubmit, false},
+	}
+	for _, tt := range tests {
+		p := &Principal{Subject: "p", Roles: tt.roles}
+		if got := p.HasRole(tt.role); got != tt.want {
+			t.Errorf("%v.HasRole(%s) = %v, se esperaba %v", tt.roles, tt.role, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Claves de metadata de las que se lee el token y donde se fija el tenant
+const (
+	authorizationMetadataKey = "authorization"
+	apiKeyMetadataKey        = "x-api-key"
+	tenantMetadataKey        = "x-tenant"
+)
+
+// Rol necesario para cada método (nombre corto); Default para los demás
+type Policy struct {
+	Methods map[string]string
+	Default string
+}
+
+// Política del balanceador: los clientes envían trabajos, el resto es administración
+var BalancerPolicy = Policy{
+	Methods: map[string]string{
+		"ProcessRequest": RoleSubmit,
+		"GetLoad":        RoleSubmit,
+		"WatchLoad":      RoleSubmit,
+		"SetFaults":      RoleAdmin,
+	},
+	Default: RoleAdmin,
+}
+
+// Política de los servidores: solo el balanceador les reenvía trabajos
+var ServerPolicy = Policy{
+	Methods: map[string]string{
+		"ProcessRequest": RoleBalancer,
+		"GetLoad":        RoleBalancer,
+		"WatchLoad":      RoleBalancer,
+		"SetFaults":      RoleAdmin,
+	},
+	Default: RoleAdmin,
+}
+
+// Rol requerido por el método
+func (p Policy) Required(fullMethod string) string {
+	method := fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+	if role, ok := p.Methods[method]; ok {
+		return role
+	}
+	return p.Default
+}
+
+// Token bearer de la metadata (o la API key de x-api-key)
+func tokenFromMetadata(md metadata.MD) string {
+	for _, value := range md.Get(authorizationMetadataKey) {
+		if scheme, token, ok := strings.Cut(value, " "); ok && strings.EqualFold(scheme, "bearer") {
+			return strings.TrimSpace(token)
+		}
+	}
+	if values := md.Get(apiKeyMetadataKey); len(values) > 0 {
+		return strings.TrimSpace(values[0])
+	}
+	return ""
+}
+
+// Autentica y autoriza la llamada; devuelve el contexto con la identidad y el
+// tenant del token impuesto sobre el que indique el cliente
+func (a *Authenticator) authorize(ctx context.Context, fullMethod string, policy Policy) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	principal, err := a.Authenticate(tokenFromMetadata(md))
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "autenticación fallida: %v", err)
+	}
+	role := policy.Required(fullMethod)
+	if !principal.HasRole(role) {
+		log.Printf("[Auth] %s sin permiso %s para %s", principal.Subject, role, fullMethod)
+		return nil, status.Errorf(codes.PermissionDenied, "%s no tiene el permiso %s", principal.Subject, role)
+	}
+	if principal.Tenant != "" {
+		md = md.Copy()
+		md.Set(tenantMetadataKey, principal.Tenant)
+		ctx = metadata.NewIncomingContext(ctx, md)
+	}
+	return newContext(ctx, principal), nil
+}
+
+// Interceptor unario que autentica y autoriza según la política
+func (a *Authenticator) UnaryInterceptor(policy Policy) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := a.authorize(ctx, info.FullMethod, policy)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// Stream con el contexto autenticado
+type authStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authStream) Context() context.Context { return s.ctx }
+
+// Interceptor de streams que autentica y autoriza según la política
+func (a *Authenticator) StreamInterceptor(policy Policy) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.authorize(ss.Context(), info.FullMethod, policy)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// Credenciales por llamada que envían un token bearer
+type TokenCredentials struct {
+	Token string
+	// Exigir TLS para no enviar el token en claro
+	RequireTLS bool
+}
+
+func (c TokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{authorizationMetadataKey: "Bearer " + c.Token}, nil
+}
+
+func (c TokenCredentials) RequireTransportSecurity() bool {
+	return c.RequireTLS
+}
@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestPolicyRequired(t *testing.T) {
+	tests := []struct {
+		policy Policy
+		method string
+		want   string
+	}{
+		{BalancerPolicy, "ProcessRequest", RoleSubmit},
+		{BalancerPolicy, "GetLoad", RoleSubmit},
+		{BalancerPolicy, "WatchLoad", RoleSubmit},
+		{BalancerPolicy, "SetFaults", RoleAdmin},
+		{BalancerPolicy, "Desconocido", RoleAdmin},
+		{ServerPolicy, "ProcessRequest", RoleBalancer},
+		{ServerPolicy, "GetLoad", RoleBalancer},
+		{ServerPolicy, "WatchLoad", RoleBalancer},
+		{ServerPolicy, "SetFaults", RoleAdmin},
+		{ServerPolicy, "Desconocido", RoleAdmin},
+	}
+	for _, tt := range tests {
+		if got := tt.policy.Required("/proto.LoadBalancerService/" + tt.method); got != tt.want {
+			t.Errorf("Required(%s) = %s, se esperaba %s", tt.method, got, tt.want)
+		}
+	}
+}
+
+// Autenticador con una API key por rol y un JWT de un tenant fijo
+func testAuthenticator(t *testing.T) (*Authenticator, string) {
+	t.Helper()
+	jwt, err := SignJWT(Claims{Subject: "cliente-a", Tenant: "a", Roles: []string{RoleSubmit}}, testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Authenticator{
+		secret: testSecret,
+		keys: map[string]Principal{
+			"submit":   {Subject: "cliente", Roles: []string{RoleSubmit}},
+			"balancer": {Subject: "balanceador", Roles: []string{RoleBalancer}},
+			"admin":    {Subject: "operador", Roles: []string{RoleAdmin}},
+		},
+	}, jwt
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	a, jwt := testAuthenticator(t)
+	tests := []struct {
+		name       string
+		policy     Policy
+		method     string
+		md         metadata.MD
+		want       codes.Code
+		wantTenant string // x-tenant que ve el manejador
+	}{
+		{"cliente envía trabajos", BalancerPolicy, "ProcessRequest", metadata.Pairs("x-api-key", "submit"), codes.OK, ""},
+		{"cliente no administra", BalancerPolicy, "SetFaults", metadata.Pairs("x-api-key", "submit"), codes.PermissionDenied, ""},
+		{"cliente no llama a servidores", ServerPolicy, "ProcessRequest", metadata.Pairs("x-api-key", "submit"), codes.PermissionDenied, ""},
+		{"balanceador llama a servidores", ServerPolicy, "GetLoad", metadata.Pairs("x-api-key", "balancer"), codes.OK, ""},
+		{"admin en método sin política", ServerPolicy, "Desconocido", metadata.Pairs("authorization", "Bearer admin"), codes.OK, ""},
+		{"sin token", BalancerPolicy, "ProcessRequest", metadata.MD{}, codes.Unauthenticated, ""},
+		{"token inválido", BalancerPolicy, "ProcessRequest", metadata.Pairs("authorization", "Bearer otro"), codes.Unauthenticated, ""},
+		{"esquema distinto de bearer", BalancerPolicy, "ProcessRequest", metadata.Pairs("authorization", "Basic submit"), codes.Unauthenticated, ""},
+		{"tenant del cliente sin tenant en el token", BalancerPolicy, "ProcessRequest", metadata.Pairs("x-api-key", "submit", "x-tenant", "b"), codes.OK, "b"},
+		{"el tenant del token se impone", BalancerPolicy, "ProcessRequest", metadata.Pairs("authorization", "Bearer "+jwt, "x-tenant", "b"), codes.OK, "a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotTenant string
+			var principal *Principal
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				md, _ := metadata.FromIncomingContext(ctx)
+				if values := md.Get(tenantMetadataKey); len(values) > 0 {
+					gotTenant = values[0]
+				}
+				principal = FromContext(ctx)
+				return "ok", nil
+			}
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+			info := &grpc.UnaryServerInfo{FullMethod: "/proto.LoadBalancerService/" + tt.method}
+			_, err := a.UnaryInterceptor(tt.policy)(ctx, nil, info, handler)
+			if code := status.Code(err); code != tt.want {
+				t.Fatalf("código %v, se esperaba %v: %v", code, tt.want, err)
+			}
+			if err != nil {
+				return
+			}
+			if principal == nil {
+				t.Fatal("el manejador no recibió la identidad")
+			}
+			if gotTenant != tt.wantTenant {
+				t.Errorf("tenant %q, se esperaba %q", gotTenant, tt.wantTenant)
+			}
+		})
+	}
+
+	// El tenant impuesto no cambia la metadata original del cliente
+	md := metadata.Pairs("authorization", "Bearer "+jwt, "x-tenant", "b")
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+	info := &grpc.UnaryServerInfo{FullMethod: "/proto.LoadBalancerService/ProcessRequest"}
+	a.UnaryInterceptor(BalancerPolicy)(ctx, nil, info, func(context.Context, interface{}) (interface{}, error) { return nil, nil })
+	if got := md.Get(tenantMetadataKey); len(got) != 1 || got[0] != "b" {
+		t.Errorf("la metadata original cambió: %v", got)
+	}
+}
+
+// Stream mínimo con un contexto fijo
+type fakeStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeStream) Context() context.Context { return s.ctx }
+
+func TestStreamInterceptor(t *testing.T) {
+	a, jwt := testAuthenticator(t)
+	tests := []struct {
+		name       string
+		policy     Policy
+		md         metadata.MD
+		want       codes.Code
+		wantTenant string
+	}{
+		{"balanceador se suscribe a un servidor", ServerPolicy, metadata.Pairs("x-api-key", "balancer"), codes.OK, ""},
+		{"cliente no se suscribe a un servidor", ServerPolicy, metadata.Pairs("x-api-key", "submit"), codes.PermissionDenied, ""},
+		{"cliente se suscribe al balanceador", BalancerPolicy, metadata.Pairs("authorization", "Bearer "+jwt, "x-tenant", "b"), codes.OK, "a"},
+		{"sin token", BalancerPolicy, metadata.MD{}, codes.Unauthenticated, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			handler := func(srv interface{}, ss grpc.ServerStream) error {
+				called = true
+				if FromContext(ss.Context()) == nil {
+					t.Error("el stream no lleva la identidad")
+				}
+				md, _ := metadata.FromIncomingContext(ss.Context())
+				if got := md.Get(tenantMetadataKey); tt.wantTenant != "" && (len(got) == 0 || got[0] != tt.wantTenant) {
+					t.Errorf("tenant %v, se esperaba %q", got, tt.wantTenant)
+				}
+				return nil
+			}
+			ss := &fakeStream{ctx: metadata.NewIncomingContext(context.Background(), tt.md)}
+			info := &grpc.StreamServerInfo{FullMethod: "/proto.LoadBalancerService/WatchLoad", IsServerStream: true}
+			err := a.StreamInterceptor(tt.policy)(nil, ss, info, handler)
+			if code := status.Code(err); code != tt.want {
+				t.Fatalf("código %v, se esperaba %v: %v", code, tt.want, err)
+			}
+			if called != (tt.want == codes.OK) {
+				t.Errorf("manejador llamado = %v con código %v", called, tt.want)
+			}
+		})
+	}
+}
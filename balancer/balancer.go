@@ -0,0 +1,217 @@
+// Package balancer contiene las estrategias con las que el balanceador elige
+// un servidor a partir de la carga observada. Las usa tanto el balanceador
+// como el simulador lbsim.
+package balancer
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+// Estado observado de un servidor al momento de elegir
+type Candidate struct {
+	Address     string
+	Load        int32   // Solicitudes en proceso
+	Capacity    int32   // Solicitudes simultáneas admitidas (0 = sin límite)
+	QueueDepth  int32   // Solicitudes esperando turno
+	Utilization float64 // (Load + QueueDepth) / Capacity
+	Weight      float64 // Peso efectivo en (0, 1], menor durante el arranque lento (0 = peso completo)
+}
+
+// Peso efectivo del candidato (1 si no se indicó)
+func (c Candidate) EffectiveWeight() float64 {
+	if c.Weight <= 0 || c.Weight > 1 {
+		return 1
+	}
+	return c.Weight
+}
+
+// Capacidad supuesta de los servidores que no declaran límite, para
+// compararlos en la misma unidad que los que sí lo declaran
+const AssumedCapacity = 10
+
+// Capacidad con la que se calcula la utilización del candidato
+func (c Candidate) capacity() float64 {
+	if c.Capacity > 0 {
+		return float64(c.Capacity)
+	}
+	return AssumedCapacity
+}
+
+// Valor usado para comparar servidores: la utilización, calculada con
+// AssumedCapacity si el servidor no declara capacidad
+func (c Candidate) Score() float64 {
+	if c.Capacity > 0 {
+		return c.Utilization
+	}
+	return float64(c.Load+c.QueueDepth) / c.capacity()
+}
+
+// Score penalizado según el peso: con peso completo es Score; con peso w el
+// servidor se ve como si tuviera (carga+1)/w - 1 solicitudes, así un servidor
+// recién agregado que reporta carga 0 no se lleva todas las solicitudes
+func (c Candidate) WeightedScore() float64 {
+	w := c.EffectiveWeight()
+	if w >= 1 {
+		return c.Score()
+	}
+	unit := 1 / c.capacity()
+	return (c.Score()+unit)/w - unit
+}
+
+// Indica si algún candidato tiene peso reducido
+func weighted(candidates []Candidate) bool {
+	for _, c := range candidates {
+		if c.EffectiveWeight() < 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// Índice elegido al azar con probabilidad proporcional al peso
+func weightedIndex(candidates []Candidate, r float64) int {
+	total := 0.0
+	for _, c := range candidates {
+		total += c.EffectiveWeight()
+	}
+	r *= total
+	for i, c := range candidates {
+		if r < c.EffectiveWeight() {
+			return i
+		}
+		r -= c.EffectiveWeight()
+	}
+	return len(candidates) - 1
+}
+
+// Estrategia de selección de servidor
+type Strategy interface {
+	Name() string
+	// Pick devuelve el índice del candidato elegido; candidates no está vacío
+	Pick(candidates []Candidate) int
+}
+
+// Constructores de las estrategias disponibles
+var strategies = map[string]func(rng *rand.Rand) Strategy{
+	"least-load":  func(*rand.Rand) Strategy { return &LeastLoad{} },
+	"round-robin": func(*rand.Rand) Strategy { return &RoundRobin{} },
+	"random":      func(rng *rand.Rand) Strategy { return &Random{rng: rng} },
+	"p2c":         func(rng *rand.Rand) Strategy { return &PowerOfTwo{rng: rng} },
+}
+
+// Nombres de las estrategias disponibles
+func Names() []string {
+	names := make([]string, 0, len(strategies))
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Crea la estrategia con el nombre dado; rng alimenta las estrategias aleatorias
+func New(name string, rng *rand.Rand) (Strategy, error) {
+	constructor, ok := strategies[name]
+	if !ok {
+		return nil, fmt.Errorf("estrategia desconocida: %s (disponibles: %v)", name, Names())
+	}
+	return constructor(rng), nil
+}
+
+// Menor utilización, penalizada por el peso
+type LeastLoad struct{}
+
+func (*LeastLoad) Name() string { return "least-load" }
+
+func (*LeastLoad) Pick(candidates []Candidate) int {
+	best := 0
+	for i, c := range candidates {
+		if c.WeightedScore() < candidates[best].WeightedScore() {
+			best = i
+		}
+	}
+	return best
+}
+
+// Turno rotativo sin mirar la carga; con pesos reducidos, turno ponderado suave
+type RoundRobin struct {
+	mu      sync.Mutex
+	next    int
+	current map[string]float64 // Crédito acumulado por servidor en el turno ponderado
+}
+
+func (*RoundRobin) Name() string { return "round-robin" }
+
+func (rr *RoundRobin) Pick(candidates []Candidate) int {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	if weighted(candidates) {
+		return rr.pickWeighted(candidates)
+	}
+	i := rr.next % len(candidates)
+	rr.next++
+	return i
+}
+
+// Turno ponderado suave: cada servidor acumula su peso y el de mayor crédito
+// paga el total
+func (rr *RoundRobin) pickWeighted(candidates []Candidate) int {
+	if rr.current == nil {
+		rr.current = make(map[string]float64)
+	}
+	best, total := 0, 0.0
+	for i, c := range candidates {
+		rr.current[c.Address] += c.EffectiveWeight()
+		total += c.EffectiveWeight()
+		if rr.current[c.Address] > rr.current[candidates[best].Address] {
+			best = i
+		}
+	}
+	rr.current[candidates[best].Address] -= total
+	return best
+}
+
+// Servidor al azar, con probabilidad proporcional al peso
+type Random struct {
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+func (*Random) Name() string { return "random" }
+
+func (r *Random) Pick(candidates []Candidate) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if weighted(candidates) {
+		return weightedIndex(candidates, r.rng.Float64())
+	}
+	return r.rng.Intn(len(candidates))
+}
+
+// Dos opciones al azar y se queda con la de menor carga penalizada por el peso
+type PowerOfTwo struct {
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+func (*PowerOfTwo) Name() string { return "p2c" }
+
+func (p *PowerOfTwo) Pick(candidates []Candidate) int {
+	if len(candidates) == 1 {
+		return 0
+	}
+	p.mu.Lock()
+	a := p.rng.Intn(len(candidates))
+	b := p.rng.Intn(len(candidates) - 1)
+	p.mu.Unlock()
+	if b >= a {
+		b++
+	}
+	if candidates[b].WeightedScore() < candidates[a].WeightedScore() {
+		return b
+	}
+	return a
+}
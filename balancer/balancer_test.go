@@ -0,0 +1,90 @@
+package balancer
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestScoreSameUnit(t *testing.T) {
+	tests := []struct {
+		name string
+		c    Candidate
+		want float64
+	}{
+		{"con capacidad", Candidate{Load: 9, Capacity: 10, Utilization: 0.9}, 0.9},
+		{"con capacidad y cola", Candidate{Load: 4, Capacity: 4, QueueDepth: 2, Utilization: 1.5}, 1.5},
+		{"sin capacidad", Candidate{Load: 1}, 1.0 / AssumedCapacity},
+		{"sin capacidad ociosa", Candidate{}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.c.Score(); got != tt.want {
+				t.Errorf("Score = %v, se esperaba %v", got, tt.want)
+			}
+		})
+	}
+
+	// En un clúster mixto un servidor sin límite con una solicitud no se ve
+	// más ocupado que uno al 90%
+	mixed := []Candidate{
+		{Address: "a", Load: 9, Capacity: 10, Utilization: 0.9},
+		{Address: "b", Load: 1},
+	}
+	if got := (&LeastLoad{}).Pick(mixed); got != 1 {
+		t.Errorf("least-load eligió %s, se esperaba b", mixed[got].Address)
+	}
+}
+
+func TestStrategies(t *testing.T) {
+	even := []Candidate{{Address: "a"}, {Address: "b"}, {Address: "c"}}
+	loaded := []Candidate{
+		{Address: "a", Load: 8, Capacity: 10, Utilization: 0.8},
+		{Address: "b", Load: 2, Capacity: 10, Utilization: 0.2},
+		{Address: "c", Load: 5, Capacity: 10, Utilization: 0.5},
+	}
+	slow := []Candidate{{Address: "a"}, {Address: "b", Weight: 0.25}}
+
+	tests := []struct {
+		name       string
+		strategy   string
+		candidates []Candidate
+		picks      int
+		want       map[string]int // Elecciones esperadas por servidor
+		tolerance  int            // Desvío admitido por servidor
+	}{
+		{"least-load elige la menor utilización", "least-load", loaded, 10, map[string]int{"b": 10}, 0},
+		{"least-load penaliza el peso", "least-load", []Candidate{
+			{Address: "a", Load: 2, Capacity: 10, Utilization: 0.2},
+			{Address: "b", Capacity: 10, Weight: 0.1},
+		}, 5, map[string]int{"a": 5}, 0},
+		{"round-robin reparte en turno", "round-robin", even, 9, map[string]int{"a": 3, "b": 3, "c": 3}, 0},
+		{"round-robin ponderado", "round-robin", slow, 10, map[string]int{"a": 8, "b": 2}, 0},
+		{"random reparte parejo", "random", even, 3000, map[string]int{"a": 1000, "b": 1000, "c": 1000}, 150},
+		{"random ponderado", "random", slow, 5000, map[string]int{"a": 4000, "b": 1000}, 200},
+		{"p2c nunca elige el más cargado", "p2c", loaded, 1000, map[string]int{"a": 0, "b": 667, "c": 333}, 80},
+		{"p2c con un candidato", "p2c", loaded[:1], 5, map[string]int{"a": 5}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(tt.strategy, rand.New(rand.NewSource(1)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make(map[string]int)
+			for i := 0; i < tt.picks; i++ {
+				got[tt.candidates[s.Pick(tt.candidates)].Address]++
+			}
+			for address, want := range tt.want {
+				if d := got[address] - want; d < -tt.tolerance || d > tt.tolerance {
+					t.Errorf("%s elegido %d veces, se esperaban %d±%d (%v)", address, got[address], want, tt.tolerance, got)
+				}
+			}
+		})
+	}
+}
+
+func TestNewUnknownStrategy(t *testing.T) {
+	if _, err := New("fastest", rand.New(rand.NewSource(1))); err == nil {
+		t.Error("se esperaba un error para una estrategia desconocida")
+	}
+}
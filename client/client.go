@@ -2,27 +2,119 @@ package main
 
 import (
 	"context"
+	"flag"
 	"log"
 	"os"
 	"strconv"
 	"sync"
+	"time"
 
+	"Distributed_load_balancer/auth"
 	pb "Distributed_load_balancer/proto"
+	"Distributed_load_balancer/tlsutil"
+	"Distributed_load_balancer/tracelog"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 )
 
+// Tenant con el que se identifican las solicitudes (opcional)
+var tenant string
+
+// Traza donde se graban las solicitudes enviadas (nil = no se graba)
+var recorder *tracelog.Writer
+
+// Tipo de trabajo de las solicitudes, usado por las reglas de enrutamiento (opcional)
+var workType string
+
+// Plazo de cada solicitud (0 = sin plazo)
+var timeout time.Duration
+
+// Clave de afinidad enviada en cada solicitud (opcional)
+var affinityKey string
+
+// Sesión emitida por el balanceador, reenviada en las solicitudes siguientes
+// si se pidió -sticky
+var session struct {
+	sync.Mutex
+	enabled bool
+	token   string
+}
+
+// Agrega a la solicitud la metadata del tenant, la afinidad y la sesión si se indicaron
+func requestContext(ctx context.Context, tenant string) context.Context {
+	if tenant != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "x-tenant", tenant)
+	}
+	if affinityKey != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "x-affinity-key", affinityKey)
+	}
+	session.Lock()
+	defer session.Unlock()
+	if session.token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "x-session", session.token)
+	}
+	return ctx
+}
+
+// Graba en la traza una solicitud enviada
+func recordRequest(ctx context.Context, req *pb.Request, sent time.Time) {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	record := tracelog.Record{
+		Time:     sent,
+		Method:   "ProcessRequest",
+		WorkID:   req.WorkId,
+		WorkType: req.WorkType,
+		Metadata: tracelog.FilterMetadata(md),
+	}
+	if values := md.Get("x-tenant"); len(values) > 0 {
+		record.Tenant = values[0]
+	}
+	if err := recorder.Write(record); err != nil {
+		log.Printf("Error al grabar la traza: %v", err)
+	}
+}
+
+// Envía una solicitud midiendo la latencia y el servidor que la atendió;
+// ctx ya debe llevar la metadata de la solicitud
+func sendTimed(ctx context.Context, client pb.LoadBalancerServiceClient, req *pb.Request) (Result, *pb.Response) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	var header metadata.MD
+	start := time.Now()
+	if recorder != nil {
+		recordRequest(ctx, req, start)
+	}
+	res, err := client.ProcessRequest(ctx, req, grpc.Header(&header))
+	r := Result{WorkID: req.WorkId, Sent: start, Latency: time.Since(start), Err: err}
+	if values := header.Get("x-backend"); len(values) > 0 {
+		r.Backend = values[0]
+	}
+	if values := header.Get("x-session"); len(values) > 0 {
+		session.Lock()
+		// El balanceador emite un token nuevo si el anterior venció o se
+		// perdió con su servidor
+		if session.enabled && session.token != values[0] {
+			session.token = values[0]
+			log.Printf("Sesión asignada por el balanceador: %s", values[0])
+		}
+		session.Unlock()
+	}
+	return r, res
+}
+
 // sendRequest envía una solicitud al balanceador de carga
-func sendRequest(client pb.LoadBalancerServiceClient, workId int32, wg *sync.WaitGroup) {
+func sendRequest(client pb.LoadBalancerServiceClient, workId int32, results chan<- Result, wg *sync.WaitGroup) {
 	defer wg.Done()
 
-	// Crear una solicitud con el ID de trabajo
-	req := &pb.Request{WorkId: workId}
-
-	// Enviar la solicitud al balanceador de carga
-	res, err := client.ProcessRequest(context.Background(), req)
-	if err != nil {
-		log.Printf("Error al procesar la solicitud %d: %v", workId, err)
+	// Enviar la solicitud con el ID de trabajo al balanceador de carga
+	r, res := sendTimed(requestContext(context.Background(), tenant), client, &pb.Request{WorkId: workId, WorkType: workType})
+	results <- r
+	if r.Err != nil {
+		log.Printf("Error al procesar la solicitud %d: %v", workId, r.Err)
 		return
 	}
 
@@ -30,20 +122,87 @@ func sendRequest(client pb.LoadBalancerServiceClient, workId int32, wg *sync.Wai
 	log.Printf("Trabajo %d - Respuesta del servidor: %s", workId, res.Result)
 }
 
+// Ejecuta una prueba de carga en lazo abierto y acumula los resultados
+func runLoadTest(gen *LoadGenerator, collector *Collector) int64 {
+	log.Printf("Prueba %s a %.1f rps durante %v (llegadas %s, concurrencia máxima %d)",
+		gen.profile.Name, gen.profile.RPS, gen.profile.Duration, gen.arrivals, gen.concurrency)
+
+	results := make(chan Result, 1024)
+	go gen.Run(context.Background(), results)
+	for r := range results {
+		collector.Add(r)
+	}
+	return gen.skipped
+}
+
+// Imprime el reporte y lo exporta a los archivos indicados
+func finishReport(report *Report, jsonFile, csvFile string) {
+	report.Print()
+	if jsonFile != "" {
+		if err := report.WriteJSON(jsonFile); err != nil {
+			log.Printf("Error al guardar el reporte JSON: %v", err)
+		}
+	}
+	if csvFile != "" {
+		if err := report.WriteCSV(csvFile); err != nil {
+			log.Printf("Error al guardar el reporte CSV: %v", err)
+		}
+	}
+}
+
 func main() {
-	// Verificar que se proporcione el número de clientes como argumento
-	if len(os.Args) < 2 {
-		log.Fatal("Debes proporcionar el número de clientes (ejemplo: ./client 10)")
+	addr := flag.String("addr", "localhost:4000", "dirección del balanceador de carga")
+	flag.StringVar(&tenant, "tenant", "", "tenant con el que se identifican las solicitudes")
+	profile := &Profile{}
+	flag.Float64Var(&profile.RPS, "rps", 0, "tasa objetivo en solicitudes por segundo (0 = modo ráfaga con N clientes)")
+	flag.StringVar(&profile.Name, "profile", "constant", "perfil de carga: constant, ramp, step, spike o soak")
+	flag.DurationVar(&profile.Duration, "duration", 30*time.Second, "duración de la prueba")
+	flag.Float64Var(&profile.StartRPS, "start-rps", 0, "tasa inicial en los perfiles ramp y step")
+	flag.DurationVar(&profile.Ramp, "ramp", 10*time.Second, "duración de la rampa en el perfil ramp")
+	flag.IntVar(&profile.Steps, "steps", 5, "número de escalones en el perfil step")
+	flag.Float64Var(&profile.SpikeRPS, "spike-rps", 0, "tasa durante el pico en el perfil spike")
+	flag.DurationVar(&profile.SpikeAt, "spike-at", 10*time.Second, "inicio del pico en el perfil spike")
+	flag.DurationVar(&profile.SpikeDuration, "spike-duration", 5*time.Second, "duración del pico en el perfil spike")
+	arrivals := flag.String("arrivals", "poisson", "proceso de llegadas: poisson o constant")
+	concurrency := flag.Int("concurrency", 0, "máximo de solicitudes en curso (0 = sin límite)")
+	reportJSON := flag.String("report-json", "", "archivo donde guardar el reporte en JSON")
+	reportCSV := flag.String("report-csv", "", "archivo donde guardar el reporte en CSV")
+	recordFile := flag.String("record", "", "archivo JSON Lines donde grabar las solicitudes enviadas")
+	replayFile := flag.String("replay", "", "archivo JSON Lines con una traza a reproducir")
+	replaySpeed := flag.Float64("replay-speed", 1, "factor de velocidad de la reproducción (2 = el doble de rápido)")
+	tlsConfig := &tlsutil.Config{}
+	tlsConfig.RegisterFlags(flag.CommandLine, "tls-", "el balanceador")
+	flag.StringVar(&tlsConfig.ServerName, "tls-server-name", "", "nombre esperado en el certificado del balanceador (vacío = el de -addr)")
+	token := flag.String("token", os.Getenv("LB_TOKEN"), "token bearer (API key o JWT) para autenticarse ante el balanceador")
+	flag.DurationVar(&timeout, "timeout", 0, "plazo de cada solicitud, propagado por el balanceador a los servidores (0 = sin plazo)")
+	flag.StringVar(&workType, "work-type", "", "tipo de trabajo de las solicitudes (ej. cpu, gpu-sim)")
+	flag.StringVar(&affinityKey, "affinity-key", "", "clave de afinidad: las solicitudes con la misma clave van al mismo servidor")
+	flag.BoolVar(&session.enabled, "sticky", false, "reenviar la sesión que emite el balanceador para seguir en el mismo servidor")
+	flag.Parse()
+
+	// Modo ráfaga: se necesita el número de clientes como argumento
+	if profile.RPS <= 0 && *replayFile == "" && flag.NArg() < 1 {
+		log.Fatal("Debes proporcionar el número de clientes (ejemplo: ./client 10 [tenant]), una tasa con -rps o una traza con -replay")
 	}
 
-	// Convertir el argumento a un número entero
-	numClients, err := strconv.Atoi(os.Args[1])
-	if err != nil {
-		log.Fatalf("Número de clientes inválido: %v", err)
+	if *recordFile != "" {
+		var err error
+		if recorder, err = tracelog.Create(*recordFile); err != nil {
+			log.Fatalf("Error al abrir la traza: %v", err)
+		}
+		defer recorder.Close()
 	}
 
 	// Conectar con el balanceador de carga
-	conn, err := grpc.Dial("localhost:4000", grpc.WithInsecure())
+	creds, err := tlsutil.ClientCredentials(*tlsConfig)
+	if err != nil {
+		log.Fatalf("Error en la configuración TLS: %v", err)
+	}
+	dialOptions := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	if *token != "" {
+		dialOptions = append(dialOptions, grpc.WithPerRPCCredentials(auth.TokenCredentials{Token: *token, RequireTLS: tlsConfig.Enabled()}))
+	}
+	conn, err := grpc.Dial(*addr, dialOptions...)
 	if err != nil {
 		log.Fatalf("Error al conectar con el balanceador de carga: %v", err)
 	}
@@ -52,15 +211,72 @@ func main() {
 	// Crear un cliente gRPC
 	client := pb.NewLoadBalancerServiceClient(conn)
 
+	if *replayFile != "" {
+		records, err := tracelog.ReadFile(*replayFile)
+		if err != nil {
+			log.Fatalf("Error al leer la traza: %v", err)
+		}
+		if *replaySpeed <= 0 {
+			log.Fatalf("El factor de velocidad debe ser positivo: %v", *replaySpeed)
+		}
+		collector := NewCollector()
+		replayer := &Replayer{
+			client:      client,
+			records:     records,
+			speed:       *replaySpeed,
+			concurrency: *concurrency,
+		}
+		replayer.Run(context.Background(), collector)
+		finishReport(collector.Report(replayer.skipped), *reportJSON, *reportCSV)
+		return
+	}
+
+	if profile.RPS > 0 {
+		if err := profile.Validate(); err != nil {
+			log.Fatalf("Perfil de carga inválido: %v", err)
+		}
+		if *arrivals != "poisson" && *arrivals != "constant" {
+			log.Fatalf("Proceso de llegadas desconocido: %s", *arrivals)
+		}
+		collector := NewCollector()
+		skipped := runLoadTest(&LoadGenerator{
+			client:      client,
+			profile:     profile,
+			arrivals:    *arrivals,
+			concurrency: *concurrency,
+			tenant:      tenant,
+		}, collector)
+		finishReport(collector.Report(skipped), *reportJSON, *reportCSV)
+		return
+	}
+
+	// Convertir el argumento a un número entero
+	numClients, err := strconv.Atoi(flag.Arg(0))
+	if err != nil {
+		log.Fatalf("Número de clientes inválido: %v", err)
+	}
+
+	// Tenant opcional como segundo argumento
+	if flag.NArg() > 1 {
+		tenant = flag.Arg(1)
+	}
+
 	// Usar un WaitGroup para esperar a que todas las goroutines terminen
 	var wg sync.WaitGroup
+	results := make(chan Result, numClients)
+	collector := NewCollector()
 
 	// Iniciar los clientes
 	for i := 0; i < numClients; i++ {
 		wg.Add(1)
-		go sendRequest(client, int32(i+1), &wg)
+		go sendRequest(client, int32(i+1), results, &wg)
 	}
 
 	// Esperar a que todas las solicitudes se completen
 	wg.Wait()
+	close(results)
+	for r := range results {
+		collector.Add(r)
+	}
+	finishReport(collector.Report(0), *reportJSON, *reportCSV)
 }
@@ -11,7 +11,7 @@ N=$1
 
 # Ejecutar el cliente N veces
 for ((i=1; i<=N; i++)); do
-    go run client/client.go $1 &
+    go run ./client $1 &
 done
 
 echo "Se han lanzado $N instancias de client.go"
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+// Bits de sub-cubeta: por encima de 2^subBucketBits cada potencia de dos usa
+// la mitad superior de las sub-cubetas, así que el error relativo máximo es
+// 1/2^(subBucketBits-1) (~1.6%)
+const subBucketBits = 7
+
+// Histograma de latencias estilo HDR: cubetas por potencia de dos, cada una
+// dividida en 2^subBucketBits sub-cubetas lineales. Registra microsegundos.
+type Histogram struct {
+	counts []int64
+	total  int64
+	sum    float64
+	min    int64
+	max    int64
+}
+
+func NewHistogram() *Histogram {
+	// 64 potencias de dos por 2^subBucketBits sub-cubetas cubre todo int64
+	return &Histogram{counts: make([]int64, 64<<subBucketBits), min: math.MaxInt64}
+}
+
+// Índice de la cubeta donde cae el valor
+func bucketIndex(v int64) int {
+	if v < 1<<subBucketBits {
+		return int(v)
+	}
+	exp := bits.Len64(uint64(v)) - subBucketBits // Bits que se descartan
+	sub := int(v >> exp)                         // Entre 2^(subBucketBits-1) y 2^subBucketBits-1 tras el desplazamiento
+	return exp<<subBucketBits + sub
+}
+
+// Valor más alto representado por la cubeta
+func bucketValue(i int) int64 {
+	exp := i >> subBucketBits
+	sub := int64(i & (1<<subBucketBits - 1))
+	if exp == 0 {
+		return sub
+	}
+	return (sub+1)<<exp - 1
+}
+
+// Registra una latencia
+func (h *Histogram) Record(d time.Duration) {
+	v := d.Microseconds()
+	if v < 0 {
+		v = 0
+	}
+	h.counts[bucketIndex(v)]++
+	h.total++
+	h.sum += float64(v)
+	h.min = min(h.min, v)
+	h.max = max(h.max, v)
+}
+
+func (h *Histogram) Count() int64 { return h.total }
+
+// Latencia en el cuantil q (0-1)
+func (h *Histogram) Quantile(q float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	target := int64(math.Ceil(q * float64(h.total)))
+	if target < 1 {
+		target = 1
+	}
+	var seen int64
+	for i, c := range h.counts {
+		seen += c
+		if seen >= target {
+			return time.Duration(min(bucketValue(i), h.max)) * time.Microsecond
+		}
+	}
+	return h.Max()
+}
+
+func (h *Histogram) Max() time.Duration { return time.Duration(h.max) * time.Microsecond }
+
+func (h *Histogram) Min() time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	return time.Duration(h.min) * time.Microsecond
+}
+
+func (h *Histogram) Mean() time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	return time.Duration(h.sum/float64(h.total)) * time.Microsecond
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestHistogramRelativeError(t *testing.T) {
+	maxErr := 1 / float64(int(1)<<(subBucketBits-1))
+	worst := 0.0
+	for _, v := range []int64{0, 1, 127, 128, 129, 255, 256, 1000, 12345, 999_999, 1 << 40, math.MaxInt64 >> 1} {
+		got := bucketValue(bucketIndex(v))
+		if got < v {
+			t.Errorf("bucketValue(bucketIndex(%d)) = %d, menor que el valor", v, got)
+		}
+		if v > 0 {
+			worst = max(worst, float64(got-v)/float64(v))
+		}
+	}
+	// El peor caso es el primer valor de cada potencia de dos: cae en la
+	// sub-cubeta más baja, la más ancha en relación al valor
+	for exp := 1; exp < 40; exp++ {
+		v := int64(1) << (subBucketBits - 1 + exp)
+		got := bucketValue(bucketIndex(v))
+		worst = max(worst, float64(got-v)/float64(v))
+	}
+	if worst > maxErr {
+		t.Errorf("error relativo %.4f, mayor que el límite %.4f", worst, maxErr)
+	}
+	if worst < maxErr/2 {
+		t.Errorf("error relativo %.4f, el límite %.4f es demasiado holgado", worst, maxErr)
+	}
+}
+
+func TestHistogramQuantiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []time.Duration
+		q       float64
+		want    time.Duration
+		maxDiff float64 // Error relativo admitido
+	}{
+		{"vacío", nil, 0.5, 0, 0},
+		{"un valor", []time.Duration{5 * time.Millisecond}, 0.99, 5 * time.Millisecond, 0},
+		{"mediana exacta en valores pequeños", []time.Duration{10 * time.Microsecond, 20 * time.Microsecond, 30 * time.Microsecond}, 0.5, 20 * time.Microsecond, 0},
+		{"p0 es el mínimo", []time.Duration{10 * time.Microsecond, 20 * time.Microsecond}, 0, 10 * time.Microsecond, 0},
+		{"p100 no pasa del máximo", []time.Duration{time.Millisecond, 1001 * time.Microsecond}, 1, 1001 * time.Microsecond, 0},
+		{"p99 de una rampa", ramp(1000, time.Millisecond), 0.99, 990 * time.Millisecond, 1.0 / 64},
+		{"p50 de una rampa", ramp(1000, time.Millisecond), 0.5, 500 * time.Millisecond, 1.0 / 64},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHistogram()
+			for _, v := range tt.values {
+				h.Record(v)
+			}
+			got := h.Quantile(tt.q)
+			if diff := math.Abs(float64(got-tt.want)) / math.Max(float64(tt.want), 1); diff > tt.maxDiff || got < tt.want {
+				t.Errorf("Quantile(%v) = %v, se esperaba %v (error admitido %.1f%%)", tt.q, got, tt.want, tt.maxDiff*100)
+			}
+		})
+	}
+}
+
+// n latencias de step, 2*step, ..., n*step
+func ramp(n int, step time.Duration) []time.Duration {
+	values := make([]time.Duration, n)
+	for i := range values {
+		values[i] = time.Duration(i+1) * step
+	}
+	return values
+}
+
+func TestHistogramSummary(t *testing.T) {
+	h := NewHistogram()
+	if h.Min() != 0 || h.Max() != 0 || h.Mean() != 0 || h.Count() != 0 {
+		t.Errorf("histograma vacío: min %v, max %v, media %v, n %d", h.Min(), h.Max(), h.Mean(), h.Count())
+	}
+	for _, d := range []time.Duration{-time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond} {
+		h.Record(d)
+	}
+	if h.Count() != 3 {
+		t.Errorf("%d registros, se esperaban 3", h.Count())
+	}
+	if h.Min() != 0 {
+		t.Errorf("mínimo %v, una latencia negativa cuenta como 0", h.Min())
+	}
+	if h.Max() != 4*time.Millisecond {
+		t.Errorf("máximo %v, se esperaba 4ms", h.Max())
+	}
+	if h.Mean() != 2*time.Millisecond {
+		t.Errorf("media %v, se esperaba 2ms", h.Mean())
+	}
+}
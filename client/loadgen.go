@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	pb "Distributed_load_balancer/proto"
+)
+
+// Perfil de carga: tasa objetivo (solicitudes por segundo) en cada instante
+type Profile struct {
+	Name          string
+	RPS           float64       // Tasa objetivo (o final en ramp/step)
+	StartRPS      float64       // Tasa inicial en ramp y step
+	Ramp          time.Duration // Duración de la rampa
+	Steps         int           // Número de escalones en step
+	SpikeRPS      float64       // Tasa durante el pico
+	SpikeAt       time.Duration // Inicio del pico
+	SpikeDuration time.Duration // Duración del pico
+	Duration      time.Duration // Duración total de la prueba
+}
+
+// Comprueba que el perfil sea válido
+func (p *Profile) Validate() error {
+	switch p.Name {
+	case "constant", "soak", "ramp", "step", "spike":
+	default:
+		return fmt.Errorf("perfil desconocido: %s", p.Name)
+	}
+	if p.RPS <= 0 {
+		return fmt.Errorf("la tasa debe ser positiva: %v", p.RPS)
+	}
+	if p.Duration <= 0 {
+		return fmt.Errorf("la duración debe ser positiva: %v", p.Duration)
+	}
+	if p.Name == "step" && p.Steps < 1 {
+		return fmt.Errorf("el perfil step necesita al menos un escalón")
+	}
+	if p.Name == "spike" && p.SpikeRPS <= 0 {
+		return fmt.Errorf("la tasa del pico debe ser positiva: %v", p.SpikeRPS)
+	}
+	return nil
+}
+
+// Tasa objetivo a los t segundos de iniciada la prueba
+func (p *Profile) Rate(t time.Duration) float64 {
+	switch p.Name {
+	case "ramp":
+		if p.Ramp <= 0 || t >= p.Ramp {
+			return p.RPS
+		}
+		return p.StartRPS + (p.RPS-p.StartRPS)*float64(t)/float64(p.Ramp)
+	case "step":
+		// Escalones iguales desde StartRPS hasta RPS repartidos en la duración
+		step := math.Min(float64(p.Steps-1), math.Floor(float64(t)/float64(p.Duration)*float64(p.Steps)))
+		if p.Steps == 1 {
+			return p.RPS
+		}
+		return p.StartRPS + (p.RPS-p.StartRPS)*step/float64(p.Steps-1)
+	case "spike":
+		if t >= p.SpikeAt && t < p.SpikeAt+p.SpikeDuration {
+			return p.SpikeRPS
+		}
+	}
+	return p.RPS
+}
+
+// Resultado de una solicitud
+type Result struct {
+	WorkID   int32
+	Intended time.Time     // Momento en que debía enviarse según el perfil (cero en modo ráfaga)
+	Sent     time.Time     // Momento real del envío
+	Latency  time.Duration // Desde el envío real hasta la respuesta
+	Backend  string        // Servidor que atendió según la metadata de respuesta
+	Err      error
+}
+
+// Generador de carga en lazo abierto: envía según el perfil sin esperar respuestas
+type LoadGenerator struct {
+	client      pb.LoadBalancerServiceClient
+	profile     *Profile
+	arrivals    string // constant o poisson
+	concurrency int    // Máximo de solicitudes en curso (0 = sin límite)
+	tenant      string
+	nextID      int32
+
+	inFlight int32
+	sent     int64
+	skipped  int64 // Llegadas descartadas por alcanzar el límite de concurrencia
+}
+
+// Intervalo hasta la siguiente llegada para la tasa dada
+func (g *LoadGenerator) interval(rate float64) time.Duration {
+	if g.arrivals == "poisson" {
+		return time.Duration(rand.ExpFloat64() / rate * float64(time.Second))
+	}
+	return time.Duration(float64(time.Second) / rate)
+}
+
+// Ejecuta la prueba y entrega cada resultado por el canal, que se cierra al terminar
+func (g *LoadGenerator) Run(ctx context.Context, results chan<- Result) {
+	defer close(results)
+
+	var wg sync.WaitGroup
+	start := time.Now()
+	next := start
+	lastReport := start
+
+	for {
+		elapsed := next.Sub(start)
+		if elapsed >= g.profile.Duration {
+			break
+		}
+
+		// Sin tráfico en este tramo del perfil: volver a consultarlo más adelante
+		rate := g.profile.Rate(elapsed)
+		if rate <= 0 {
+			next = next.Add(100 * time.Millisecond)
+			continue
+		}
+
+		// Esperar hasta el momento programado de la llegada
+		if wait := time.Until(next); wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				wg.Wait()
+				return
+			}
+		}
+
+		if g.concurrency > 0 && atomic.LoadInt32(&g.inFlight) >= int32(g.concurrency) {
+			atomic.AddInt64(&g.skipped, 1)
+		} else {
+			atomic.AddInt32(&g.inFlight, 1)
+			atomic.AddInt64(&g.sent, 1)
+			g.nextID++
+			wg.Add(1)
+			go func(workID int32, intended time.Time) {
+				defer wg.Done()
+				defer atomic.AddInt32(&g.inFlight, -1)
+				results <- g.send(ctx, workID, intended)
+			}(g.nextID, next)
+		}
+
+		// Progreso periódico (útil en pruebas soak largas)
+		if now := time.Now(); now.Sub(lastReport) >= 10*time.Second {
+			lastReport = now
+			log.Printf("[%v] tasa objetivo: %.1f rps, enviadas: %d, descartadas: %d, en curso: %d",
+				elapsed.Truncate(time.Second), rate,
+				atomic.LoadInt64(&g.sent), atomic.LoadInt64(&g.skipped), atomic.LoadInt32(&g.inFlight))
+		}
+
+		next = next.Add(g.interval(rate))
+	}
+	wg.Wait()
+}
+
+// Envía una solicitud y mide su latencia
+func (g *LoadGenerator) send(ctx context.Context, workID int32, intended time.Time) Result {
+	r, _ := sendTimed(requestContext(ctx, g.tenant), g.client, &pb.Request{WorkId: workID, WorkType: workType})
+	r.Intended = intended
+	return r
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestProfileValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		p       Profile
+		wantErr bool
+	}{
+		{"constante", Profile{Name: "constant", RPS: 10, Duration: time.Second}, false},
+		{"desconocido", Profile{Name: "burst", RPS: 10, Duration: time.Second}, true},
+		{"sin tasa", Profile{Name: "constant", Duration: time.Second}, true},
+		{"step sin escalones", Profile{Name: "step", RPS: 10, Duration: time.Second}, true},
+		{"spike", Profile{Name: "spike", RPS: 10, SpikeRPS: 100, Duration: time.Second}, false},
+		{"spike sin tasa de pico", Profile{Name: "spike", RPS: 10, Duration: time.Second}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.p.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, se esperaba error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProfileRate(t *testing.T) {
+	constant := Profile{Name: "constant", RPS: 10, Duration: 4 * time.Second}
+	ramp := Profile{Name: "ramp", StartRPS: 10, RPS: 40, Ramp: 2 * time.Second, Duration: 4 * time.Second}
+	step := Profile{Name: "step", StartRPS: 10, RPS: 40, Steps: 4, Duration: 4 * time.Second}
+	spike := Profile{Name: "spike", RPS: 10, SpikeRPS: 100, SpikeAt: time.Second, SpikeDuration: 2 * time.Second, Duration: 4 * time.Second}
+	tests := []struct {
+		name string
+		p    Profile
+		at   time.Duration
+		want float64
+	}{
+		{"constant al inicio", constant, 0, 10},
+		{"constant al final", constant, constant.Duration, 10},
+		{"soak", Profile{Name: "soak", RPS: 5, Duration: time.Hour}, 30 * time.Minute, 5},
+		{"ramp al inicio", ramp, 0, 10},
+		{"ramp a la mitad", ramp, time.Second, 25},
+		{"ramp justo antes del final", ramp, ramp.Ramp - time.Nanosecond, 40 - 30/float64(ramp.Ramp)},
+		{"ramp al terminar", ramp, ramp.Ramp, 40},
+		{"ramp después de terminar", ramp, 3 * time.Second, 40},
+		{"ramp sin duración", Profile{Name: "ramp", StartRPS: 10, RPS: 40, Duration: time.Second}, 0, 40},
+		{"step al inicio", step, 0, 10},
+		{"step justo antes del segundo escalón", step, time.Second - time.Nanosecond, 10},
+		{"step en el segundo escalón", step, time.Second, 20},
+		{"step en el último escalón", step, 3 * time.Second, 40},
+		{"step al final", step, step.Duration, 40},
+		{"step después del final", step, 2 * step.Duration, 40},
+		{"step de un escalón", Profile{Name: "step", StartRPS: 10, RPS: 40, Steps: 1, Duration: time.Second}, 0, 40},
+		{"spike antes del pico", spike, spike.SpikeAt - time.Nanosecond, 10},
+		{"spike al iniciar el pico", spike, spike.SpikeAt, 100},
+		{"spike justo antes de terminar el pico", spike, spike.SpikeAt + spike.SpikeDuration - time.Nanosecond, 100},
+		{"spike al terminar el pico", spike, spike.SpikeAt + spike.SpikeDuration, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.p.Rate(tt.at); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Rate(%v) = %v, se esperaba %v", tt.at, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	pb "Distributed_load_balancer/proto"
+	"Distributed_load_balancer/tracelog"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// Reproduce una traza respetando los tiempos entre llegadas originales
+type Replayer struct {
+	client      pb.LoadBalancerServiceClient
+	records     []tracelog.Record
+	speed       float64 // Factor de velocidad (2 = el doble de rápido)
+	concurrency int     // Máximo de solicitudes en curso (0 = sin límite)
+
+	inFlight int32
+	skipped  int64
+}
+
+// Contexto con la metadata grabada de la solicitud
+func replayContext(ctx context.Context, r tracelog.Record) context.Context {
+	var pairs []string
+	for key, value := range r.Metadata {
+		pairs = append(pairs, key, value)
+	}
+	if r.Tenant != "" && r.Metadata["x-tenant"] == "" {
+		pairs = append(pairs, "x-tenant", r.Tenant)
+	}
+	if len(pairs) == 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, pairs...)
+}
+
+// Envía todas las solicitudes de la traza y acumula los resultados
+func (rp *Replayer) Run(ctx context.Context, collector *Collector) {
+	if len(rp.records) == 0 {
+		log.Printf("La traza está vacía")
+		return
+	}
+	first := rp.records[0].Time
+	span := rp.records[len(rp.records)-1].Time.Sub(first)
+	log.Printf("Reproduciendo %d solicitudes (%v de traza) a velocidad %.2fx",
+		len(rp.records), span, rp.speed)
+
+	results := make(chan Result, 1024)
+	done := make(chan struct{})
+	go func() {
+		for r := range results {
+			collector.Add(r)
+		}
+		close(done)
+	}()
+
+	var wg sync.WaitGroup
+	start := time.Now()
+	for _, record := range rp.records {
+		if record.Method != "" && record.Method != "ProcessRequest" {
+			continue
+		}
+
+		// Momento programado según el tiempo original escalado por la velocidad
+		intended := start.Add(time.Duration(float64(record.Time.Sub(first)) / rp.speed))
+		if wait := time.Until(intended); wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				wg.Wait()
+				close(results)
+				<-done
+				return
+			}
+		}
+
+		if rp.concurrency > 0 && atomic.LoadInt32(&rp.inFlight) >= int32(rp.concurrency) {
+			rp.skipped++
+			continue
+		}
+		atomic.AddInt32(&rp.inFlight, 1)
+		wg.Add(1)
+		go func(record tracelog.Record, intended time.Time) {
+			defer wg.Done()
+			defer atomic.AddInt32(&rp.inFlight, -1)
+			r, _ := sendTimed(replayContext(ctx, record), rp.client, &pb.Request{WorkId: record.WorkID, WorkType: record.WorkType})
+			r.Intended = intended
+			results <- r
+		}(record, intended)
+	}
+	wg.Wait()
+	close(results)
+	<-done
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/status"
+)
+
+// Percentiles incluidos en el reporte
+var reportQuantiles = []struct {
+	name string
+	q    float64
+}{
+	{"p50", 0.50},
+	{"p90", 0.90},
+	{"p99", 0.99},
+	{"p99.9", 0.999},
+}
+
+// Acumula los resultados de una prueba
+type Collector struct {
+	start     time.Time
+	service   *Histogram       // Latencia desde el envío real
+	corrected *Histogram       // Latencia desde el envío programado (lazo abierto)
+	codes     map[string]int64 // Resultados por código gRPC
+	backends  map[string]int64 // Respuestas por servidor según la metadata
+}
+
+func NewCollector() *Collector {
+	return &Collector{
+		start:     time.Now(),
+		service:   NewHistogram(),
+		corrected: NewHistogram(),
+		codes:     make(map[string]int64),
+		backends:  make(map[string]int64),
+	}
+}
+
+// Registra un resultado. Los histogramas, también el corregido, solo guardan
+// latencias de respuestas exitosas: un error rápido (límite de tasa, circuito
+// abierto) bajaría los percentiles; los errores se cuentan por código
+func (c *Collector) Add(r Result) {
+	c.codes[status.Code(r.Err).String()]++
+	if r.Err != nil {
+		return
+	}
+	c.service.Record(r.Latency)
+	if !r.Intended.IsZero() {
+		// Corrección de omisión coordinada: medir desde cuándo debió enviarse
+		c.corrected.Record(r.Sent.Sub(r.Intended) + r.Latency)
+	}
+	if r.Backend != "" {
+		c.backends[r.Backend]++
+	}
+}
+
+// Latencias resumidas en milisegundos
+type LatencySummary struct {
+	Count       int64              `json:"count"`
+	MinMs       float64            `json:"min_ms"`
+	MeanMs      float64            `json:"mean_ms"`
+	Percentiles map[string]float64 `json:"percentiles_ms"`
+	MaxMs       float64            `json:"max_ms"`
+}
+
+func ms(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+
+func summarize(h *Histogram) *LatencySummary {
+	s := &LatencySummary{
+		Count:       h.Count(),
+		MinMs:       ms(h.Min()),
+		MeanMs:      ms(h.Mean()),
+		Percentiles: make(map[string]float64),
+		MaxMs:       ms(h.Max()),
+	}
+	for _, p := range reportQuantiles {
+		s.Percentiles[p.name] = ms(h.Quantile(p.q))
+	}
+	return s
+}
+
+// Reporte final de una prueba
+type Report struct {
+	DurationSec   float64          `json:"duration_sec"`
+	Total         int64            `json:"total"`
+	Succeeded     int64            `json:"succeeded"`
+	Failed        int64            `json:"failed"`
+	Skipped       int64            `json:"skipped"`
+	ThroughputRPS float64          `json:"throughput_rps"`
+	Codes         map[string]int64 `json:"codes"`
+	Latency       *LatencySummary  `json:"latency"`
+	Corrected     *LatencySummary  `json:"corrected_latency,omitempty"`
+	Backends      map[string]int64 `json:"backends"`
+}
+
+// Genera el reporte; skipped son las llegadas descartadas por concurrencia
+func (c *Collector) Report(skipped int64) *Report {
+	elapsed := time.Since(c.start)
+	r := &Report{
+		DurationSec: elapsed.Seconds(),
+		Skipped:     skipped,
+		Codes:       c.codes,
+		Latency:     summarize(c.service),
+		Backends:    c.backends,
+	}
+	for code, n := range c.codes {
+		r.Total += n
+		if code == "OK" {
+			r.Succeeded += n
+		} else {
+			r.Failed += n
+		}
+	}
+	if elapsed > 0 {
+		r.ThroughputRPS = float64(r.Succeeded) / elapsed.Seconds()
+	}
+	if c.corrected.Count() > 0 {
+		r.Corrected = summarize(c.corrected)
+	}
+	return r
+}
+
+// Claves de un mapa ordenadas
+func sortedKeys(m map[string]int64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Línea con los percentiles de un resumen
+func (s *LatencySummary) line() string {
+	parts := []string{fmt.Sprintf("min %.2f", s.MinMs), fmt.Sprintf("media %.2f", s.MeanMs)}
+	for _, p := range reportQuantiles {
+		parts = append(parts, fmt.Sprintf("%s %.2f", p.name, s.Percentiles[p.name]))
+	}
+	parts = append(parts, fmt.Sprintf("max %.2f", s.MaxMs))
+	return strings.Join(parts, " | ") + " ms"
+}
+
+// Imprime el reporte en la terminal
+func (r *Report) Print() {
+	fmt.Println("=== Resumen de la prueba ===")
+	fmt.Printf("Duración: %.2fs  Total: %d  Exitosas: %d  Con error: %d  Descartadas: %d\n",
+		r.DurationSec, r.Total, r.Succeeded, r.Failed, r.Skipped)
+	fmt.Printf("Throughput: %.2f rps\n", r.ThroughputRPS)
+
+	fmt.Println("\nResultados por código:")
+	for _, code := range sortedKeys(r.Codes) {
+		fmt.Printf("  %-20s %d\n", code, r.Codes[code])
+	}
+
+	fmt.Println("\nLatencia:")
+	fmt.Printf("  servicio:  %s\n", r.Latency.line())
+	if r.Corrected != nil {
+		fmt.Printf("  corregida: %s\n", r.Corrected.line())
+	}
+
+	fmt.Println("\nReparto por servidor:")
+	for _, backend := range sortedKeys(r.Backends) {
+		n := r.Backends[backend]
+		fmt.Printf("  %-22s %6d  %5.1f%%\n", backend, n, 100*float64(n)/float64(max(r.Succeeded, 1)))
+	}
+}
+
+// Guarda el reporte en JSON
+func (r *Report) WriteJSON(filename string) error {
+	content, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, content, 0644)
+}
+
+// Guarda el reporte en CSV con filas sección,nombre,valor
+func (r *Report) WriteCSV(filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	row := func(section, name string, value interface{}) {
+		writer.Write([]string{section, name, fmt.Sprint(value)})
+	}
+
+	row("Seccion", "Nombre", "Valor")
+	row("resumen", "duration_sec", fmt.Sprintf("%.3f", r.DurationSec))
+	row("resumen", "total", r.Total)
+	row("resumen", "succeeded", r.Succeeded)
+	row("resumen", "failed", r.Failed)
+	row("resumen", "skipped", r.Skipped)
+	row("resumen", "throughput_rps", fmt.Sprintf("%.3f", r.ThroughputRPS))
+	for _, code := range sortedKeys(r.Codes) {
+		row("codigo", code, r.Codes[code])
+	}
+	latencyRows := func(section string, s *LatencySummary) {
+		row(section, "min_ms", fmt.Sprintf("%.3f", s.MinMs))
+		row(section, "mean_ms", fmt.Sprintf("%.3f", s.MeanMs))
+		for _, p := range reportQuantiles {
+			row(section, p.name+"_ms", fmt.Sprintf("%.3f", s.Percentiles[p.name]))
+		}
+		row(section, "max_ms", fmt.Sprintf("%.3f", s.MaxMs))
+	}
+	latencyRows("latencia", r.Latency)
+	if r.Corrected != nil {
+		latencyRows("latencia_corregida", r.Corrected)
+	}
+	for _, backend := range sortedKeys(r.Backends) {
+		row("servidor", backend, r.Backends[backend])
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCollectorCorrectedLatency(t *testing.T) {
+	intended := time.Now()
+	tests := []struct {
+		name          string
+		result        Result
+		wantService   time.Duration // 0 = no se registra
+		wantCorrected time.Duration
+	}{
+		{"a tiempo", Result{Intended: intended, Sent: intended, Latency: 10 * time.Millisecond}, 10 * time.Millisecond, 10 * time.Millisecond},
+		{"enviada con atraso", Result{Intended: intended, Sent: intended.Add(90 * time.Millisecond), Latency: 10 * time.Millisecond}, 10 * time.Millisecond, 100 * time.Millisecond},
+		{"modo ráfaga sin envío programado", Result{Sent: intended, Latency: 10 * time.Millisecond}, 10 * time.Millisecond, 0},
+		{"error con atraso", Result{Intended: intended, Sent: intended.Add(time.Second), Latency: time.Millisecond, Err: status.Error(codes.Unavailable, "caído")}, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCollector()
+			c.Add(tt.result)
+			check := func(name string, h *Histogram, want time.Duration) {
+				if want == 0 {
+					if h.Count() != 0 {
+						t.Errorf("latencia %s registrada: %v", name, h.Max())
+					}
+					return
+				}
+				if h.Count() != 1 || h.Max() != want {
+					t.Errorf("latencia %s %v (%d registros), se esperaba %v", name, h.Max(), h.Count(), want)
+				}
+			}
+			check("de servicio", c.service, tt.wantService)
+			check("corregida", c.corrected, tt.wantCorrected)
+		})
+	}
+}
+
+func TestCollectorReport(t *testing.T) {
+	c := NewCollector()
+	intended := time.Now()
+	for i := 0; i < 3; i++ {
+		c.Add(Result{Intended: intended, Sent: intended, Latency: time.Millisecond, Backend: "backend-1"})
+	}
+	c.Add(Result{Intended: intended, Sent: intended, Err: status.Error(codes.ResourceExhausted, "límite")})
+
+	r := c.Report(2)
+	if r.Total != 4 || r.Succeeded != 3 || r.Failed != 1 || r.Skipped != 2 {
+		t.Errorf("total %d, exitosas %d, fallidas %d, descartadas %d", r.Total, r.Succeeded, r.Failed, r.Skipped)
+	}
+	if r.Codes["ResourceExhausted"] != 1 || r.Backends["backend-1"] != 3 {
+		t.Errorf("códigos %v, servidores %v", r.Codes, r.Backends)
+	}
+	// Los percentiles solo cuentan las exitosas, también los corregidos
+	if r.Latency.Count != 3 || r.Corrected == nil || r.Corrected.Count != 3 {
+		t.Errorf("latencias registradas: %+v, corregidas: %+v", r.Latency, r.Corrected)
+	}
+}
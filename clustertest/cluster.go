@@ -0,0 +1,261 @@
+// Package clustertest levanta en el mismo proceso un balanceador y N servidores
+// conectados por bufconn, para probar el enrutamiento de extremo a extremo sin
+// abrir puertos. Permite detener, pausar o retrasar servidores individuales.
+package clustertest
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"Distributed_load_balancer/faults"
+	"Distributed_load_balancer/lb"
+	pb "Distributed_load_balancer/proto"
+	"Distributed_load_balancer/server"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const bufSize = 1 << 20
+
+// Configuración del clúster
+type Options struct {
+	Servers int           // Número de servidores
+	Server  server.Config // Plantilla para cada servidor; Port y Faults se ignoran
+	LB      lb.Config     // Configuración del balanceador; Servers y DialOptions se completan solos
+	// Interceptores adicionales del balanceador, antes del inyector de fallas
+	Interceptors []grpc.UnaryServerInterceptor
+}
+
+// Servidor del clúster
+type Backend struct {
+	Address string
+	Server  *server.Server
+	Faults  *faults.Injector
+
+	workload *server.Workload // Trabajo original, restaurado con SetLatency(0)
+	mu       sync.Mutex
+	listener *bufconn.Listener // nil si el servidor está detenido
+	grpc     *grpc.Server
+	paused   chan struct{} // Se cierra al reanudar (nil = no pausado)
+}
+
+// Clúster en memoria
+type Cluster struct {
+	LB       *lb.LoadBalancer
+	Faults   *faults.Injector // Fallas del balanceador
+	Client   pb.LoadBalancerServiceClient
+	Backends []*Backend
+
+	tb       testing.TB
+	listener *bufconn.Listener
+	grpc     *grpc.Server
+	conn     *grpc.ClientConn
+}
+
+// Inicia el clúster; se detiene solo al terminar la prueba
+func Start(tb testing.TB, opts Options) *Cluster {
+	tb.Helper()
+	if opts.Servers < 1 {
+		opts.Servers = 1
+	}
+	c := &Cluster{tb: tb}
+
+	for i := 0; i < opts.Servers; i++ {
+		cfg := opts.Server
+		cfg.Port = fmt.Sprintf("backend-%d", i+1)
+		cfg.Faults = faults.NewInjector("Server "+cfg.Port, nil)
+		b := &Backend{Address: cfg.Port, Server: server.New(cfg), Faults: cfg.Faults}
+		b.workload = b.Server.Workload()
+		c.Backends = append(c.Backends, b)
+		b.start()
+	}
+
+	// El balanceador llega a los servidores a través de sus listeners en memoria
+	lbCfg := opts.LB
+	lbCfg.Servers = nil
+	for _, b := range c.Backends {
+		lbCfg.Servers = append(lbCfg.Servers, b.Address)
+	}
+	lbCfg.DialOptions = append(lbCfg.DialOptions, grpc.WithContextDialer(c.dialBackend))
+	if lbCfg.Faults == nil {
+		lbCfg.Faults = faults.NewInjector("Balanceador", nil)
+	}
+	c.Faults = lbCfg.Faults
+	c.LB = lb.New(lbCfg)
+
+	c.listener = bufconn.Listen(bufSize)
+	interceptors := append(append([]grpc.UnaryServerInterceptor{}, opts.Interceptors...), c.Faults.UnaryInterceptor)
+	c.grpc = grpc.NewServer(grpc.ChainUnaryInterceptor(interceptors...))
+	pb.RegisterLoadBalancerServiceServer(c.grpc, c.LB)
+	go c.grpc.Serve(c.listener)
+
+	conn, err := grpc.NewClient("passthrough:///balancer",
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return c.listener.DialContext(ctx)
+		}))
+	if err != nil {
+		tb.Fatalf("error al conectar con el balanceador: %v", err)
+	}
+	c.conn = conn
+	c.Client = pb.NewLoadBalancerServiceClient(conn)
+
+	tb.Cleanup(c.Close)
+	return c
+}
+
+// Conecta con el servidor de la dirección dada, si está en marcha
+func (c *Cluster) dialBackend(ctx context.Context, addr string) (net.Conn, error) {
+	b := c.Backend(addr)
+	if b == nil {
+		return nil, fmt.Errorf("servidor desconocido: %s", addr)
+	}
+	b.mu.Lock()
+	listener := b.listener
+	b.mu.Unlock()
+	if listener == nil {
+		return nil, fmt.Errorf("servidor %s detenido", addr)
+	}
+	return listener.DialContext(ctx)
+}
+
+// Detiene el balanceador y todos los servidores
+func (c *Cluster) Close() {
+	c.conn.Close()
+	c.grpc.Stop()
+	for _, b := range c.Backends {
+		b.Resume()
+		b.stop()
+	}
+}
+
+// Servidor con la dirección dada (nil si no existe)
+func (c *Cluster) Backend(addr string) *Backend {
+	for _, b := range c.Backends {
+		if b.Address == addr {
+			return b
+		}
+	}
+	return nil
+}
+
+// Envía una solicitud al balanceador y devuelve el servidor que la atendió
+func (c *Cluster) Send(ctx context.Context, workID int32) (string, *pb.Response, error) {
+	var header metadata.MD
+	res, err := c.Client.ProcessRequest(ctx, &pb.Request{WorkId: workID}, grpc.Header(&header))
+	backend := ""
+	if values := header.Get("x-backend"); len(values) > 0 {
+		backend = values[0]
+	}
+	return backend, res, err
+}
+
+// Envía n solicitudes seguidas y cuenta cuántas atendió cada servidor;
+// falla la prueba ante el primer error
+func (c *Cluster) SendN(ctx context.Context, n int) map[string]int {
+	c.tb.Helper()
+	routed := make(map[string]int)
+	for i := 1; i <= n; i++ {
+		backend, _, err := c.Send(ctx, int32(i))
+		if err != nil {
+			c.tb.Fatalf("solicitud %d fallida: %v", i, err)
+		}
+		routed[backend]++
+	}
+	return routed
+}
+
+// Solicitudes completadas por cada servidor
+func (c *Cluster) Distribution() map[string]int {
+	handled := make(map[string]int)
+	for _, b := range c.Backends {
+		handled[b.Address] = b.Server.Handled()
+	}
+	return handled
+}
+
+// Pone en marcha el servidor gRPC sobre un listener nuevo
+func (b *Backend) start() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.listener != nil {
+		return
+	}
+	b.listener = bufconn.Listen(bufSize)
+	b.grpc = grpc.NewServer(grpc.ChainUnaryInterceptor(b.gate, b.Faults.UnaryInterceptor))
+	pb.RegisterLoadBalancerServiceServer(b.grpc, b.Server)
+	go b.grpc.Serve(b.listener)
+}
+
+func (b *Backend) stop() {
+	b.mu.Lock()
+	grpcServer := b.grpc
+	b.listener, b.grpc = nil, nil
+	b.mu.Unlock()
+	if grpcServer != nil {
+		grpcServer.Stop()
+	}
+}
+
+// Detiene el servidor cortando las conexiones abiertas; las nuevas conexiones fallan
+func (b *Backend) Kill() {
+	b.stop()
+}
+
+// Vuelve a levantar el servidor conservando sus contadores
+func (b *Backend) Restart() {
+	b.start()
+}
+
+// Retiene todas las llamadas al servidor hasta Resume
+func (b *Backend) Pause() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.paused == nil {
+		b.paused = make(chan struct{})
+	}
+}
+
+// Libera las llamadas retenidas por Pause
+func (b *Backend) Resume() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.paused != nil {
+		close(b.paused)
+		b.paused = nil
+	}
+}
+
+// Hace que cada solicitud tarde d dentro del servidor (0 = trabajo original);
+// las solicitudes lentas cuentan como carga en curso, igual que un servidor
+// sobrecargado
+func (b *Backend) SetLatency(d time.Duration) {
+	if d <= 0 {
+		b.Server.SetWorkload(b.workload)
+		return
+	}
+	b.Server.SetWorkload(&server.Workload{Model: "fixed", Mean: d, Speed: 1})
+}
+
+// Interceptor que retiene las llamadas mientras el servidor está pausado
+func (b *Backend) gate(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	b.mu.Lock()
+	paused := b.paused
+	b.mu.Unlock()
+	if paused != nil {
+		select {
+		case <-paused:
+		case <-ctx.Done():
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+	}
+	return handler(ctx, req)
+}
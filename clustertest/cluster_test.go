@@ -0,0 +1,420 @@
+package clustertest
+
+import (
+	"context"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"Distributed_load_balancer/balancer"
+	"Distributed_load_balancer/lb"
+	pb "Distributed_load_balancer/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestRouting(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy balancer.Strategy
+		servers  int
+		requests int
+		want     map[string]int
+	}{
+		{"round-robin reparte parejo", &balancer.RoundRobin{}, 3, 9, map[string]int{"backend-1": 3, "backend-2": 3, "backend-3": 3}},
+		{"least-load sin carga elige el primero", &balancer.LeastLoad{}, 2, 4, map[string]int{"backend-1": 4}},
+		{"un solo servidor", nil, 1, 3, map[string]int{"backend-1": 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Start(t, Options{Servers: tt.servers, LB: lb.Config{Strategy: tt.strategy}})
+			routed := c.SendN(context.Background(), tt.requests)
+			handled := c.Distribution()
+			for _, b := range c.Backends {
+				if routed[b.Address] != tt.want[b.Address] || handled[b.Address] != tt.want[b.Address] {
+					t.Errorf("%s: enrutadas %d, atendidas %d, se esperaban %d", b.Address, routed[b.Address], handled[b.Address], tt.want[b.Address])
+				}
+			}
+		})
+	}
+}
+
+func TestFailover(t *testing.T) {
+	tests := []struct {
+		name    string
+		disable func(*Backend)
+	}{
+		{"servidor detenido", (*Backend).Kill},
+		{"servidor pausado", (*Backend).Pause},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Start(t, Options{Servers: 3, LB: lb.Config{
+				Strategy:  &balancer.RoundRobin{},
+				Deadlines: lb.DeadlineConfig{ProbeTimeout: 100 * time.Millisecond},
+			}})
+			c.SendN(context.Background(), 3)
+			down := c.Backends[1]
+			before := down.Server.Handled()
+			tt.disable(down)
+
+			routed := c.SendN(context.Background(), 6)
+			if routed[down.Address] != 0 || down.Server.Handled() != before {
+				t.Errorf("%s recibió solicitudes estando fuera de servicio: %v", down.Address, routed)
+			}
+			if routed["backend-1"]+routed["backend-3"] != 6 {
+				t.Errorf("las solicitudes no pasaron a los otros servidores: %v", routed)
+			}
+
+			down.Resume()
+			down.Restart()
+			if routed := c.SendN(context.Background(), 6); routed[down.Address] == 0 {
+				t.Errorf("%s no volvió a recibir solicitudes: %v", down.Address, routed)
+			}
+		})
+	}
+}
+
+func TestLatencyAwareSelection(t *testing.T) {
+	const measured = 6 // Solicitudes medidas, en orden y de a una
+	tests := []struct {
+		name     string
+		strategy balancer.Strategy
+		want     int // Solicitudes medidas que recibe el servidor lento
+	}{
+		{"least-load lo evita", &balancer.LeastLoad{}, 0},
+		{"round-robin no mira la carga", &balancer.RoundRobin{}, measured / 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Start(t, Options{Servers: 3, LB: lb.Config{Strategy: tt.strategy}})
+			slow := c.Backends[0] // Primero, para que los empates lo favorezcan
+			original := slow.Server.Workload()
+			inFlight := func() int32 {
+				load, _ := slow.Server.GetLoad(context.Background(), &pb.LoadRequest{})
+				return load.Load
+			}
+
+			// Ocupa el servidor lento con una solicitud que no termina hasta
+			// cancelarla y espera a que se vea como carga antes de medir
+			slow.SetLatency(time.Hour)
+			blockCtx, unblock := context.WithCancel(context.Background())
+			var blockers sync.WaitGroup
+			defer blockers.Wait()
+			defer unblock()
+			for i := 0; inFlight() == 0; i++ {
+				if i == 3*len(c.Backends) {
+					t.Fatal("ninguna solicitud llegó al servidor lento")
+				}
+				done := make(chan struct{})
+				blockers.Add(1)
+				go func() {
+					defer blockers.Done()
+					defer close(done)
+					c.Send(blockCtx, 0)
+				}()
+				// Hasta que la solicitud termine en otro servidor u ocupe al lento
+				for waiting := true; waiting && inFlight() == 0; {
+					select {
+					case <-done:
+						waiting = false
+					case <-time.After(time.Millisecond):
+					}
+				}
+			}
+
+			slow.SetLatency(50 * time.Millisecond)
+			routed := c.SendN(context.Background(), measured)
+			if routed[slow.Address] != tt.want {
+				t.Errorf("el servidor lento recibió %d de %d solicitudes (%v), se esperaban %d", routed[slow.Address], measured, routed, tt.want)
+			}
+
+			// Sin la latencia vuelve a atender como los demás
+			unblock()
+			blockers.Wait()
+			slow.SetLatency(0)
+			if w := slow.Server.Workload(); w != original {
+				t.Errorf("SetLatency(0) no restauró el trabajo original: %+v", w)
+			}
+		})
+	}
+}
+
+func TestAccessLogRecordsFailures(t *testing.T) {
+	responses := filepath.Join(t.TempDir(), "responses.csv")
+	c := Start(t, Options{Servers: 1, LB: lb.Config{ResponsesFile: responses}})
+	c.Faults.Set(&pb.FaultConfig{Rules: []*pb.FaultRule{
+		{Method: "ProcessRequest", Outbound: true, MinWorkId: 2, MaxWorkId: 2, ErrorCode: int32(codes.Internal)},
+	}})
+	for id := int32(1); id <= 2; id++ {
+		c.Send(context.Background(), id)
+	}
+
+	want := map[string]string{"1": "OK", "2": codes.Internal.String()}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		got := make(map[string]string)
+		if file, err := os.Open(responses); err == nil {
+			records, _ := csv.NewReader(file).ReadAll()
+			file.Close()
+			for _, record := range records[min(1, len(records)):] {
+				got[record[1]] = record[len(record)-1]
+			}
+		}
+		if reflect.DeepEqual(got, want) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("estados registrados %v, se esperaban %v", got, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestBackendTimeoutsTripBreaker(t *testing.T) {
+	tests := []struct {
+		name    string
+		call    func(c *Cluster) error
+		tripped bool
+	}{
+		{"el servidor no responde dentro del plazo", func(c *Cluster) error {
+			ctx, cancel := context.WithTimeout(context.Background(), 400*time.Millisecond)
+			defer cancel()
+			_, _, err := c.Send(ctx, 1)
+			return err
+		}, true},
+		{"el servidor no responde dentro del plazo por defecto", func(c *Cluster) error {
+			_, _, err := c.Send(context.Background(), 1)
+			return err
+		}, true},
+		{"el cliente cancela", func(c *Cluster) error {
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(200*time.Millisecond, cancel)
+			_, _, err := c.Send(ctx, 1)
+			return err
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Start(t, Options{Servers: 1, LB: lb.Config{
+				Breakers: &lb.BreakersFile{Default: lb.BreakerConfig{ConsecutiveFailures: 1, OpenMs: 60000}},
+				// Margen para que venza el plazo del servidor antes que el del cliente
+				Deadlines: lb.DeadlineConfig{Default: 400 * time.Millisecond, Margin: 100 * time.Millisecond},
+			}})
+			c.Backends[0].SetLatency(2 * time.Second)
+			if err := tt.call(c); err == nil {
+				t.Fatal("se esperaba un error")
+			}
+			status := c.LB.Backends()[0]
+			if tripped := status.Breaker.State == lb.BreakerOpen; tripped != tt.tripped {
+				t.Errorf("circuito %s, se esperaba abierto: %v", status.Breaker.State, tt.tripped)
+			}
+			if status.Excluded != tt.tripped || status.Weight <= 0 {
+				t.Errorf("excluido %v con peso %.2f, se esperaba excluido: %v", status.Excluded, status.Weight, tt.tripped)
+			}
+		})
+	}
+}
+
+func TestWatchLoad(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(c *Cluster)
+		want  func(s lb.BackendStatus) bool
+	}{
+		{"suscripción activa", func(*Cluster) {}, func(s lb.BackendStatus) bool { return s.Watching }},
+		{"métricas propias", func(c *Cluster) { c.Backends[0].Server.SetMetric("cola", 7) },
+			func(s lb.BackendStatus) bool { return s.Metrics["cola"] == 7 }},
+		{"carga empujada", func(c *Cluster) {
+			c.Backends[0].SetLatency(time.Second)
+			go c.Send(context.Background(), 1)
+		}, func(s lb.BackendStatus) bool { return s.Load == 1 }},
+		{"corte de la suscripción", func(c *Cluster) { c.Backends[0].Kill() },
+			func(s lb.BackendStatus) bool { return !s.Watching }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Start(t, Options{Servers: 1, LB: lb.Config{
+				Watch: &lb.WatchConfig{Interval: time.Hour, MinInterval: time.Millisecond, Retry: time.Hour},
+			}})
+			waitBackend(t, c, func(s lb.BackendStatus) bool { return s.Watching })
+			tt.setup(c)
+			waitBackend(t, c, tt.want)
+		})
+	}
+}
+
+// Espera hasta que el estado del primer servidor cumpla la condición
+func waitBackend(t *testing.T, c *Cluster, cond func(lb.BackendStatus) bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		status := c.LB.Backends()[0]
+		if cond(status) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("estado inesperado: %+v", status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestLoadReportSkipsProbe(t *testing.T) {
+	tests := []struct {
+		name       string
+		reportAge  time.Duration
+		wantProbes int32
+	}{
+		{"sin usar la carga informada", 0, 3},
+		{"carga informada reciente", time.Hour, 1}, // Solo antes de la primera respuesta
+		{"carga informada vencida", time.Nanosecond, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var probes atomic.Int32
+			countProbes := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+				if strings.HasSuffix(method, "/GetLoad") {
+					probes.Add(1)
+				}
+				return invoker(ctx, method, req, reply, cc, opts...)
+			}
+			c := Start(t, Options{Servers: 1, LB: lb.Config{
+				LoadReportAge: tt.reportAge,
+				DialOptions:   []grpc.DialOption{grpc.WithChainUnaryInterceptor(countProbes)},
+			}})
+			c.SendN(context.Background(), 3)
+			if got := probes.Load(); got != tt.wantProbes {
+				t.Errorf("%d consultas de carga, se esperaban %d", got, tt.wantProbes)
+			}
+		})
+	}
+}
+
+func TestSessionAffinity(t *testing.T) {
+	// Envía una solicitud con la metadata dada; devuelve el servidor y la
+	// sesión emitida por el balanceador
+	send := func(c *Cluster, pairs ...string) (string, string, error) {
+		var header metadata.MD
+		ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs(pairs...))
+		_, err := c.Client.ProcessRequest(ctx, &pb.Request{WorkId: 1}, grpc.Header(&header))
+		backend, session := "", ""
+		if values := header.Get("x-backend"); len(values) > 0 {
+			backend = values[0]
+		}
+		if values := header.Get("x-session"); len(values) > 0 {
+			session = values[0]
+		}
+		return backend, session, err
+	}
+	// Envía n solicitudes y devuelve los servidores distintos que las atendieron
+	spread := func(t *testing.T, c *Cluster, n int, pairs ...string) map[string]bool {
+		t.Helper()
+		backends := make(map[string]bool)
+		for i := 0; i < n; i++ {
+			backend, _, err := send(c, pairs...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			backends[backend] = true
+		}
+		return backends
+	}
+
+	tests := []struct {
+		name string
+		run  func(t *testing.T, c *Cluster)
+	}{
+		{"clave del cliente", func(t *testing.T, c *Cluster) {
+			if got := spread(t, c, 6, "x-affinity-key", "carrito-1"); len(got) != 1 {
+				t.Errorf("la sesión pasó por %v", got)
+			}
+		}},
+		{"sesión emitida", func(t *testing.T, c *Cluster) {
+			first, session, err := send(c)
+			if err != nil || session == "" {
+				t.Fatalf("sin sesión emitida: %v", err)
+			}
+			got := spread(t, c, 6, "x-session", session)
+			if len(got) != 1 || !got[first] {
+				t.Errorf("la sesión fijada a %s pasó por %v", first, got)
+			}
+			if _, again, _ := send(c, "x-session", session); again != "" {
+				t.Errorf("se emitió otra sesión (%s) para una vigente", again)
+			}
+		}},
+		{"sesión inventada", func(t *testing.T, c *Cluster) {
+			_, session, err := send(c, "x-session", "inventado")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if session == "" || session == "inventado" {
+				t.Errorf("sesión emitida %q para un token inventado", session)
+			}
+			// El token inventado no queda fijado a ningún servidor
+			if got := spread(t, c, 6, "x-session", "inventado"); len(got) == 1 {
+				t.Errorf("el token inventado quedó fijado a %v", got)
+			}
+		}},
+		{"servidor fijado drenado", func(t *testing.T, c *Cluster) {
+			pinned, _, err := send(c, "x-affinity-key", "carrito-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.LB.Drain(pinned)
+			got := spread(t, c, 6, "x-affinity-key", "carrito-1")
+			if len(got) != 1 || got[pinned] {
+				t.Errorf("con %s drenado la sesión pasó por %v", pinned, got)
+			}
+		}},
+		{"servidor fijado con el circuito abierto", func(t *testing.T, c *Cluster) {
+			pinned, _, err := send(c, "x-affinity-key", "carrito-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Otras solicitudes fallan en el servidor fijado y abren su circuito
+			c.Faults.Set(&pb.FaultConfig{Rules: []*pb.FaultRule{
+				{Method: "ProcessRequest", Outbound: true, Backend: pinned, ErrorCode: int32(codes.Unavailable)},
+			}})
+			for i := 0; !breakerOpen(c, pinned); i++ {
+				if i == 10 {
+					t.Fatalf("el circuito de %s no se abrió", pinned)
+				}
+				send(c)
+			}
+			c.Faults.Set(&pb.FaultConfig{})
+			got := spread(t, c, 6, "x-affinity-key", "carrito-1")
+			if len(got) != 1 || got[pinned] {
+				t.Errorf("con el circuito de %s abierto la sesión pasó por %v", pinned, got)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Start(t, Options{Servers: 3, LB: lb.Config{
+				Strategy: &balancer.RoundRobin{},
+				Affinity: &lb.AffinityConfig{IssueSessions: true},
+				Breakers: &lb.BreakersFile{Default: lb.BreakerConfig{ConsecutiveFailures: 1, OpenMs: 60000}},
+			}})
+			tt.run(t, c)
+		})
+	}
+}
+
+// Indica si el circuito del servidor está abierto
+func breakerOpen(c *Cluster, address string) bool {
+	for _, b := range c.LB.Backends() {
+		if b.Address == address {
+			return b.Breaker != nil && b.Breaker.State == lb.BreakerOpen
+		}
+	}
+	return false
+}
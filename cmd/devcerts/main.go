@@ -0,0 +1,154 @@
+// devcerts genera una CA local y certificados para probar TLS y mTLS entre el
+// cliente, el balanceador y los servidores. No usar en producción.
+//
+// Ejemplo:
+//
+//	devcerts -out certs -hosts localhost,127.0.0.1 -clients cliente,admin
+//
+// Genera ca.pem, balanceador.pem, servidor.pem y un certificado por cliente,
+// cada uno con su clave en <nombre>-key.pem. El certificado del balanceador
+// sirve también como certificado de cliente ante los servidores (mTLS).
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Certificado con su clave
+type issued struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func serialNumber() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
+
+// Escribe un bloque PEM en el archivo
+func writePEM(filename, blockType string, der []byte, mode os.FileMode) error {
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return fmt.Errorf("error al crear %s: %v", filename, err)
+	}
+	defer file.Close()
+	return pem.Encode(file, &pem.Block{Type: blockType, Bytes: der})
+}
+
+// Firma la plantilla con el emisor (nil = autofirmado) y guarda certificado y clave
+func create(dir, name string, template *x509.Certificate, issuer *issued) (*issued, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	if template.SerialNumber, err = serialNumber(); err != nil {
+		return nil, err
+	}
+	parent, signer := template, key
+	if issuer != nil {
+		parent, signer = issuer.cert, issuer.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signer)
+	if err != nil {
+		return nil, fmt.Errorf("error al firmar %s: %v", name, err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := writePEM(filepath.Join(dir, name+".pem"), "CERTIFICATE", der, 0644); err != nil {
+		return nil, err
+	}
+	if err := writePEM(filepath.Join(dir, name+"-key.pem"), "EC PRIVATE KEY", keyDER, 0600); err != nil {
+		return nil, err
+	}
+	log.Printf("Generado %s (CN=%s)", filepath.Join(dir, name+".pem"), template.Subject.CommonName)
+	return &issued{cert: cert, key: key}, nil
+}
+
+// Plantilla de un certificado hoja con los usos dados
+func leaf(cn string, hosts []string, validity time.Duration, usages ...x509.ExtKeyUsage) *x509.Certificate {
+	t := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: cn, Organization: []string{"Distributed_load_balancer dev"}},
+		NotBefore:   time.Now().Add(-time.Hour),
+		NotAfter:    time.Now().Add(validity),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: usages,
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			t.IPAddresses = append(t.IPAddresses, ip)
+		} else {
+			t.DNSNames = append(t.DNSNames, h)
+		}
+	}
+	return t
+}
+
+func splitList(list string) []string {
+	var out []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func main() {
+	log.SetFlags(0)
+	dir := flag.String("out", "certs", "directorio de salida")
+	hosts := flag.String("hosts", "localhost,127.0.0.1", "nombres y direcciones IP del balanceador y los servidores")
+	clients := flag.String("clients", "cliente", "identidades (CN) de los certificados de cliente, separadas por comas")
+	days := flag.Int("days", 30, "días de validez de los certificados")
+	flag.Parse()
+
+	if err := os.MkdirAll(*dir, 0755); err != nil {
+		log.Fatalf("Error al crear %s: %v", *dir, err)
+	}
+	validity := time.Duration(*days) * 24 * time.Hour
+	hostList := splitList(*hosts)
+
+	ca, err := create(*dir, "ca", &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "Distributed_load_balancer dev CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(validity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}, nil)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	// El balanceador atiende a los clientes y se conecta a los servidores
+	if _, err := create(*dir, "balanceador", leaf("balanceador", hostList, validity,
+		x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth), ca); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if _, err := create(*dir, "servidor", leaf("servidor", hostList, validity, x509.ExtKeyUsageServerAuth), ca); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	for _, client := range splitList(*clients) {
+		if _, err := create(*dir, client, leaf(client, nil, validity, x509.ExtKeyUsageClientAuth), ca); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"Distributed_load_balancer/auth"
+	"Distributed_load_balancer/faults"
+	pb "Distributed_load_balancer/proto"
+	"Distributed_load_balancer/tlsutil"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Reemplaza las fallas inyectadas en el servidor o balanceador indicado
+func runFaults(args []string) error {
+	fs := flag.NewFlagSet("faults", flag.ExitOnError)
+	addr := fs.String("addr", "localhost:4000", "dirección del servidor o balanceador")
+	file := fs.String("file", "", "archivo JSON con las reglas de fallas")
+	clear := fs.Bool("clear", false, "eliminar todas las fallas")
+	tlsConfig := &tlsutil.Config{}
+	tlsConfig.RegisterFlags(fs, "tls-", "el servidor o balanceador")
+	token := fs.String("token", os.Getenv("LB_TOKEN"), "token bearer con permiso admin")
+	fs.Parse(args)
+
+	cfg := &pb.FaultConfig{}
+	switch {
+	case *clear:
+	case *file != "":
+		var err error
+		if cfg, err = faults.LoadFile(*file); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("indica -file o -clear")
+	}
+
+	creds, err := tlsutil.ClientCredentials(*tlsConfig)
+	if err != nil {
+		return err
+	}
+	conn, err := grpc.Dial(*addr, dialOptions(creds, *token, tlsConfig.Enabled())...)
+	if err != nil {
+		return fmt.Errorf("error al conectar con %s: %v", *addr, err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	active, err := pb.NewLoadBalancerServiceClient(conn).SetFaults(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("error al configurar fallas en %s: %v", *addr, err)
+	}
+	out, _ := protojson.MarshalOptions{Multiline: true}.Marshal(active)
+	fmt.Printf("Fallas activas en %s:\n%s\n", *addr, out)
+	return nil
+}
+
+// Opciones de conexión con las credenciales TLS y el token (si se indicó)
+func dialOptions(creds credentials.TransportCredentials, token string, secure bool) []grpc.DialOption {
+	options := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	if token != "" {
+		options = append(options, grpc.WithPerRPCCredentials(auth.TokenCredentials{Token: token, RequireTLS: secure}))
+	}
+	return options
+}
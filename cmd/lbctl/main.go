@@ -0,0 +1,57 @@
+// lbctl es la herramienta de administración del balanceador y los servidores.
+//
+// Uso:
+//
+//	lbctl faults -addr localhost:50051 -file fallas.json
+//	lbctl faults -addr localhost:4000 -clear
+//	lbctl top -servers servers.txt -lb-http localhost:8080
+//	lbctl token -secret jwt.secret -sub equipo-a -tenant equipo-a -roles submit
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+)
+
+// Subcomando de lbctl
+type command struct {
+	help string
+	run  func(args []string) error
+}
+
+var commands = map[string]command{
+	"faults": {"configura las fallas inyectadas en un servidor o el balanceador", runFaults},
+	"top":    {"muestra en la terminal la carga y la salud de los servidores", runTop},
+	"token":  {"firma un JWT de prueba con el secreto compartido", runToken},
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Uso: lbctl <comando> [opciones]")
+	fmt.Fprintln(os.Stderr, "\nComandos:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", name, commands[name].help)
+	}
+}
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+	if err := cmd.run(os.Args[2:]); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package main
+
+// Sin soporte de modo sin búfer: las teclas se leen al presionar Enter
+func rawTerminal() (func(), error) {
+	return func() {}, nil
+}
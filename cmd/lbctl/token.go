@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"Distributed_load_balancer/auth"
+)
+
+// Firma un JWT para pruebas con el secreto compartido
+func runToken(args []string) error {
+	fs := flag.NewFlagSet("token", flag.ExitOnError)
+	secretFile := fs.String("secret", "", "archivo con el secreto HMAC")
+	subject := fs.String("sub", "", "sujeto del token")
+	tenant := fs.String("tenant", "", "tenant asociado (vacío = el que indique cada solicitud)")
+	roles := fs.String("roles", auth.RoleSubmit, "roles separados por comas: submit, balancer o admin")
+	ttl := fs.Duration("ttl", 24*time.Hour, "vigencia del token (0 = sin vencimiento)")
+	fs.Parse(args)
+
+	if *secretFile == "" || *subject == "" {
+		return fmt.Errorf("indica -secret y -sub")
+	}
+	secret, err := auth.ReadSecretFromFile(*secretFile)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	claims := auth.Claims{Subject: *subject, Tenant: *tenant, IssuedAt: now.Unix()}
+	for _, role := range strings.Split(*roles, ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			claims.Roles = append(claims.Roles, role)
+		}
+	}
+	if *ttl > 0 {
+		claims.ExpiresAt = now.Add(*ttl).Unix()
+	}
+	token, err := auth.SignJWT(claims, secret)
+	if err != nil {
+		return err
+	}
+	fmt.Println(token)
+	return nil
+}
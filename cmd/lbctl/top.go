@@ -0,0 +1,390 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"Distributed_load_balancer/lb"
+	pb "Distributed_load_balancer/proto"
+	"Distributed_load_balancer/tlsutil"
+
+	"google.golang.org/grpc"
+)
+
+// Secuencias ANSI
+const (
+	clearScreen = "\033[H\033[2J"
+	red         = "\033[31m"
+	yellow      = "\033[33m"
+	reverse     = "\033[7m"
+	reset       = "\033[0m"
+)
+
+// Fila de la tabla de top
+type topRow struct {
+	address string
+	state   string // OK, CAÍDO, DRENADO, EXPULSADO, ABIERTO o SEMIABIERTO
+	err     string
+	load    *pb.LoadResponse
+	rps     float64
+	errRate float64
+	latency float64 // ms
+	fromLB  bool    // Hay estadísticas del balanceador para este servidor
+}
+
+// Columnas por las que se puede ordenar: tecla, título y comparación
+type topColumn struct {
+	key   byte
+	title string
+	less  func(a, b *topRow) bool
+}
+
+func loadOf(r *topRow) float64 {
+	if r.load == nil {
+		return -1
+	}
+	return float64(r.load.Load)
+}
+
+func utilOf(r *topRow) float64 {
+	if r.load == nil {
+		return -1
+	}
+	return r.load.Utilization
+}
+
+var topColumns = []topColumn{
+	{'n', "Servidor", func(a, b *topRow) bool { return a.address < b.address }},
+	{'s', "Estado", func(a, b *topRow) bool { return a.state > b.state }},
+	{'l', "Carga", func(a, b *topRow) bool { return loadOf(a) > loadOf(b) }},
+	{'u', "Util", func(a, b *topRow) bool { return utilOf(a) > utilOf(b) }},
+	{'r', "Sol/s", func(a, b *topRow) bool { return a.rps > b.rps }},
+	{'e', "Errores", func(a, b *topRow) bool { return a.errRate > b.errRate }},
+	{'t', "Lat ms", func(a, b *topRow) bool { return a.latency > b.latency }},
+}
+
+// Monitor que consulta los servidores y el estado del balanceador
+type topMonitor struct {
+	servers []string
+	lbURL   string
+	client  *http.Client
+	creds   []grpc.DialOption // Credenciales hacia los servidores
+	conns   map[string]*grpc.ClientConn
+	last    map[string]lb.BackendStatus
+	lastAt  time.Time
+	lbErr   error
+}
+
+// Estado del balanceador a través de su puerto HTTP
+func (m *topMonitor) fetchStatus() (*lb.Status, error) {
+	res, err := m.client.Get(m.lbURL + "/status")
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("estado HTTP %s", res.Status)
+	}
+	status := &lb.Status{}
+	if err := json.NewDecoder(res.Body).Decode(status); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// Consulta la carga de un servidor
+func getLoad(conn *grpc.ClientConn) (*pb.LoadResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 800*time.Millisecond)
+	defer cancel()
+	return pb.NewLoadBalancerServiceClient(conn).GetLoad(ctx, &pb.LoadRequest{})
+}
+
+// Toma una muestra de todos los servidores
+func (m *topMonitor) sample() []*topRow {
+	var status *lb.Status
+	if m.lbURL != "" {
+		status, m.lbErr = m.fetchStatus()
+	}
+
+	// Servidores del archivo más los que conoce el balanceador
+	lbBackends := make(map[string]lb.BackendStatus)
+	addresses := append([]string{}, m.servers...)
+	if status != nil {
+		for _, b := range status.Backends {
+			if !contains(addresses, b.Address) {
+				addresses = append(addresses, b.Address)
+			}
+			lbBackends[b.Address] = b
+		}
+	}
+	rows := make([]*topRow, len(addresses))
+	var wg sync.WaitGroup
+	for i, address := range addresses {
+		rows[i] = &topRow{address: address, state: "OK"}
+		// Las conexiones se reutilizan entre muestras
+		conn, ok := m.conns[address]
+		if !ok {
+			var err error
+			if conn, err = grpc.Dial(address, m.creds...); err != nil {
+				rows[i].state, rows[i].err = "CAÍDO", err.Error()
+				continue
+			}
+			m.conns[address] = conn
+		}
+		wg.Add(1)
+		go func(r *topRow, conn *grpc.ClientConn) {
+			defer wg.Done()
+			load, err := getLoad(conn)
+			if err != nil {
+				r.state, r.err = "CAÍDO", err.Error()
+				return
+			}
+			r.load = load
+		}(rows[i], conn)
+	}
+	wg.Wait()
+
+	// Tasas a partir de los contadores acumulados del balanceador
+	now := time.Now()
+	elapsed := now.Sub(m.lastAt).Seconds()
+	for _, r := range rows {
+		b, ok := lbBackends[r.address]
+		if !ok {
+			continue
+		}
+		r.fromLB = true
+		if r.state == "OK" {
+			if b.Draining {
+				r.state = "DRENADO"
+			} else if !b.Healthy {
+				r.state, r.err = "EXPULSADO", b.LastError
+			} else if b.Breaker != nil && b.Breaker.State == lb.BreakerOpen {
+				r.state = "ABIERTO"
+			} else if b.Breaker != nil && b.Breaker.State == lb.BreakerHalfOpen {
+				r.state = "SEMIABIERTO"
+			}
+		}
+		if prev, ok := m.last[r.address]; ok && elapsed > 0 {
+			n := float64(b.Requests - prev.Requests)
+			r.rps = n / elapsed
+			if n > 0 {
+				r.errRate = float64(b.Errors-prev.Errors) / n
+				r.latency = (b.LatencyMs - prev.LatencyMs) / n
+			}
+		}
+	}
+	m.last = lbBackends
+	m.lastAt = now
+	return rows
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Barra de carga de ancho fijo
+func loadBar(load *pb.LoadResponse, maxLoad int32, width int) string {
+	if load == nil {
+		return strings.Repeat(" ", width)
+	}
+	fraction := load.Utilization
+	if load.Capacity == 0 {
+		fraction = 0
+		if maxLoad > 0 {
+			fraction = float64(load.Load) / float64(maxLoad)
+		}
+	}
+	filled := int(fraction*float64(width) + 0.5)
+	filled = max(0, min(filled, width))
+	return strings.Repeat("█", filled) + strings.Repeat("·", width-filled)
+}
+
+// Dibuja una pantalla completa
+func (m *topMonitor) render(rows []*topRow, sortBy int, interactive bool) string {
+	sort.SliceStable(rows, func(i, j int) bool { return topColumns[sortBy].less(rows[i], rows[j]) })
+	var maxLoad int32
+	for _, r := range rows {
+		if r.load != nil && r.load.Load > maxLoad {
+			maxLoad = r.load.Load
+		}
+	}
+
+	// Colores solo en la terminal interactiva
+	paint := func(code string) string {
+		if !interactive {
+			return ""
+		}
+		return code
+	}
+
+	var sb strings.Builder
+	sb.WriteString(paint(clearScreen))
+	fmt.Fprintf(&sb, "lbctl top - %s - %d servidores", time.Now().Format("15:04:05"), len(rows))
+	switch {
+	case m.lbURL == "":
+	case m.lbErr != nil:
+		fmt.Fprintf(&sb, " - balanceador no disponible: %v", m.lbErr)
+	default:
+		fmt.Fprintf(&sb, " - estadísticas de %s", m.lbURL)
+	}
+	sb.WriteString("\n\n")
+
+	widths := []int{22, 12, 30, 7, 8, 10, 9}
+	for i, c := range topColumns {
+		title := fmt.Sprintf("%-*s", widths[i], fmt.Sprintf("%s(%c)", c.title, c.key))
+		if i == sortBy {
+			title = paint(reverse) + title + paint(reset)
+		}
+		sb.WriteString(title + " ")
+	}
+	sb.WriteString("\n")
+
+	for _, r := range rows {
+		color := ""
+		switch r.state {
+		case "CAÍDO":
+			color = paint(red)
+		case "ABIERTO":
+			color = paint(red)
+		case "DRENADO", "EXPULSADO", "SEMIABIERTO":
+			color = paint(yellow)
+		}
+		load, util := "-", "-"
+		if r.load != nil {
+			load = fmt.Sprintf("%d", r.load.Load)
+			if r.load.Capacity > 0 {
+				load = fmt.Sprintf("%d/%d", r.load.Load, r.load.Capacity)
+				util = fmt.Sprintf("%.0f%%", 100*r.load.Utilization)
+			}
+		}
+		rps, errRate, latency := "-", "-", "-"
+		if r.fromLB {
+			rps, errRate, latency = fmt.Sprintf("%.1f", r.rps), fmt.Sprintf("%.1f%%", 100*r.errRate), fmt.Sprintf("%.1f", r.latency)
+		}
+		fmt.Fprintf(&sb, "%s%-22s %-12s %s %-9s %-7s %-8s %-10s %-9s%s\n", color,
+			r.address, r.state, loadBar(r.load, maxLoad, 20), load, util, rps, errRate, latency, paint(reset))
+		if r.err != "" {
+			fmt.Fprintf(&sb, "%s  └ %s%s\n", color, r.err, paint(reset))
+		}
+	}
+	if interactive {
+		sb.WriteString("\nOrdenar: n s l u r e t · salir: q\n")
+	}
+	return sb.String()
+}
+
+// Panel en la terminal con la carga de cada servidor
+func runTop(args []string) error {
+	fs := flag.NewFlagSet("top", flag.ExitOnError)
+	serversFile := fs.String("servers", "servers.txt", "archivo con la lista de servidores (vacío = los que informe el balanceador)")
+	lbAddr := fs.String("lb-http", "localhost:8080", "dirección HTTP del balanceador para sus estadísticas (vacío = no consultar)")
+	interval := fs.Duration("interval", time.Second, "intervalo de actualización")
+	sortKey := fs.String("sort", "n", "columna inicial de orden: n, s, l, u, r, e o t")
+	once := fs.Bool("once", false, "muestra una sola muestra sin limpiar la pantalla y termina")
+	tlsConfig := &tlsutil.Config{}
+	tlsConfig.RegisterFlags(fs, "tls-", "los servidores")
+	token := fs.String("token", os.Getenv("LB_TOKEN"), "token bearer para consultar la carga de los servidores")
+	fs.Parse(args)
+
+	creds, err := tlsutil.ClientCredentials(*tlsConfig)
+	if err != nil {
+		return err
+	}
+
+	m := &topMonitor{
+		client: &http.Client{Timeout: 800 * time.Millisecond},
+		creds:  dialOptions(creds, *token, tlsConfig.Enabled()),
+		conns:  make(map[string]*grpc.ClientConn),
+	}
+	defer func() {
+		for _, conn := range m.conns {
+			conn.Close()
+		}
+	}()
+	if *lbAddr != "" {
+		m.lbURL = "http://" + *lbAddr
+	}
+	if *serversFile != "" {
+		servers, err := lb.ReadServersFromFile(*serversFile)
+		if err != nil && m.lbURL == "" {
+			return err
+		}
+		m.servers = servers
+	}
+
+	sortBy := -1
+	for i, c := range topColumns {
+		if len(*sortKey) == 1 && c.key == (*sortKey)[0] {
+			sortBy = i
+		}
+	}
+	if sortBy < 0 {
+		return fmt.Errorf("columna de orden desconocida: %s", *sortKey)
+	}
+
+	if *once {
+		// Dos muestras para poder calcular las tasas
+		m.sample()
+		time.Sleep(*interval)
+		fmt.Print(m.render(m.sample(), sortBy, false))
+		return nil
+	}
+
+	// Teclas sin esperar Enter; se restaura la terminal al salir
+	restore, err := rawTerminal()
+	if err != nil {
+		return err
+	}
+	defer restore()
+	keys := make(chan byte)
+	go func() {
+		buf := make([]byte, 1)
+		for {
+			if n, err := os.Stdin.Read(buf); err != nil || n == 0 {
+				close(keys)
+				return
+			}
+			keys <- buf[0]
+		}
+	}()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	rows := m.sample()
+	for {
+		fmt.Print(m.render(rows, sortBy, true))
+		select {
+		case <-signals:
+			return nil
+		case key, ok := <-keys:
+			if !ok || key == 'q' {
+				return nil
+			}
+			for i, c := range topColumns {
+				if c.key == key {
+					sortBy = i
+				}
+			}
+		case <-ticker.C:
+			rows = m.sample()
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"sort"
+	"time"
+)
+
+// Parámetros del análisis
+type Options struct {
+	Bucket      time.Duration // Ancho de los intervalos de la serie temporal
+	Tolerance   time.Duration // Diferencia máxima entre una fila del balanceador y la del servidor
+	MinFairness float64       // Índice de Jain por debajo del cual se marca desbalance
+}
+
+// Resultado del análisis
+type Report struct {
+	Files       []string      `json:"files"`
+	LBRows      int           `json:"lb_rows"`
+	ServerRows  int           `json:"server_rows"`
+	Skipped     int           `json:"skipped_rows"`
+	Start       time.Time     `json:"start"`
+	End         time.Time     `json:"end"`
+	LoadSource  string        `json:"load_source"` // balancer, server o none
+	Servers     []ServerStats `json:"servers"`
+	Timeline    []Bucket      `json:"timeline"`
+	Fairness    float64       `json:"fairness"`
+	Imbalanced  bool          `json:"imbalanced"`
+	WorkIDs     WorkIDStats   `json:"work_ids"`
+	Consistency Consistency   `json:"consistency"`
+}
+
+// Solicitudes y carga al asignar por servidor
+type ServerStats struct {
+	Address     string  `json:"address"`
+	Requests    int     `json:"requests"`
+	Errors      int     `json:"errors"` // Solicitudes asignadas que fallaron
+	Share       float64 `json:"share"`
+	LoadSamples int     `json:"load_samples"`
+	MeanLoad    float64 `json:"mean_load"`
+	MaxLoad     int32   `json:"max_load"`
+}
+
+// Solicitudes por servidor en un intervalo de tiempo
+type Bucket struct {
+	Start    time.Time      `json:"start"`
+	Requests map[string]int `json:"requests"`
+}
+
+// Cantidad máxima de rangos faltantes que se listan en el reporte
+const maxMissingRanges = 100
+
+type IDCount struct {
+	WorkID int32 `json:"work_id"`
+	Count  int   `json:"count"`
+}
+
+// Trabajos duplicados o faltantes según el balanceador
+type WorkIDStats struct {
+	Min        int32     `json:"min"`
+	Max        int32     `json:"max"`
+	Distinct   int       `json:"distinct"`
+	Duplicated int       `json:"duplicated"` // work_id asignados más de una vez
+	Repeats    int       `json:"repeats"`    // Asignaciones de más sobre la primera
+	Top        []IDCount `json:"top_duplicated"`
+	Missing    int64     `json:"missing"`        // work_id del rango que nadie vio
+	Gaps       []IDRange `json:"missing_ranges"` // Primeros maxMissingRanges rangos faltantes
+}
+
+// Rango de work_id consecutivos, ambos extremos incluidos
+type IDRange struct {
+	From int32 `json:"from"`
+	To   int32 `json:"to"`
+}
+
+// Correspondencia entre filas del balanceador y de los servidores
+type Consistency struct {
+	Matched        int     `json:"matched"`
+	Failed         int     `json:"failed"`      // Filas del balanceador con error, que no se emparejan
+	LBOnly         int     `json:"lb_only"`     // El balanceador registró una respuesta sin fila del servidor
+	ServerOnly     int     `json:"server_only"` // El servidor procesó un trabajo que el balanceador no registró
+	ResultMismatch int     `json:"result_mismatch"`
+	Ratio          float64 `json:"ratio"` // Matched / filas del balanceador sin error
+}
+
+// Índice de equidad de Jain sobre las solicitudes por servidor
+func jain(values []float64) float64 {
+	var sum, squares float64
+	for _, v := range values {
+		sum += v
+		squares += v * v
+	}
+	if squares == 0 {
+		return 1
+	}
+	return sum * sum / (float64(len(values)) * squares)
+}
+
+func abs(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// Empareja cada fila del balanceador con la fila más cercana del servidor para el mismo trabajo
+func match(data *logData, tolerance time.Duration, c *Consistency) {
+	byID := make(map[int32][]*serverRow)
+	for _, row := range data.servers {
+		byID[row.WorkID] = append(byID[row.WorkID], row)
+	}
+	for _, row := range data.lb {
+		if row.Failed {
+			c.Failed++
+			continue
+		}
+		var best *serverRow
+		for _, candidate := range byID[row.WorkID] {
+			if candidate.matched || abs(row.Time.Sub(candidate.Time)) > tolerance {
+				continue
+			}
+			if best == nil || abs(row.Time.Sub(candidate.Time)) < abs(row.Time.Sub(best.Time)) {
+				best = candidate
+			}
+		}
+		if best == nil {
+			c.LBOnly++
+			continue
+		}
+		best.matched, row.matched = true, best
+		c.Matched++
+		if best.Result != row.Result {
+			c.ResultMismatch++
+		}
+	}
+	for _, row := range data.servers {
+		if !row.matched {
+			c.ServerOnly++
+		}
+	}
+	if answered := len(data.lb) - c.Failed; answered > 0 {
+		c.Ratio = float64(c.Matched) / float64(answered)
+	}
+}
+
+// Carga del servidor al asignarle el trabajo; las filas antiguas del balanceador
+// no la registran y se usa la carga que informó el servidor al terminar
+func assignmentLoad(data *logData) (string, map[*lbRow]int32) {
+	loads := make(map[*lbRow]int32)
+	for _, row := range data.lb {
+		if row.Load >= 0 {
+			loads[row] = row.Load
+		}
+	}
+	if len(loads) > 0 {
+		return "balancer", loads
+	}
+
+	for _, row := range data.lb {
+		if row.matched != nil {
+			loads[row] = row.matched.Load
+		}
+	}
+	if len(loads) > 0 {
+		return "server", loads
+	}
+	return "none", loads
+}
+
+// Analiza los registros leídos
+func analyze(data *logData, opts Options) *Report {
+	r := &Report{LBRows: len(data.lb), ServerRows: len(data.servers), Skipped: data.skipped}
+	sort.SliceStable(data.lb, func(i, j int) bool { return data.lb[i].Time.Before(data.lb[j].Time) })
+	sort.SliceStable(data.servers, func(i, j int) bool { return data.servers[i].Time.Before(data.servers[j].Time) })
+
+	match(data, opts.Tolerance, &r.Consistency)
+	var loads map[*lbRow]int32
+	r.LoadSource, loads = assignmentLoad(data)
+
+	// Solicitudes y carga por servidor
+	stats := make(map[string]*ServerStats)
+	var buckets []Bucket
+	counts := make(map[int32]int)
+	for _, row := range data.lb {
+		if r.Start.IsZero() || row.Time.Before(r.Start) {
+			r.Start = row.Time
+		}
+		if row.Time.After(r.End) {
+			r.End = row.Time
+		}
+
+		s, ok := stats[row.Server]
+		if !ok {
+			s = &ServerStats{Address: row.Server}
+			stats[row.Server] = s
+		}
+		s.Requests++
+		if row.Failed {
+			s.Errors++
+		}
+		if load, ok := loads[row]; ok {
+			s.MeanLoad += float64(load)
+			s.LoadSamples++
+			if load > s.MaxLoad {
+				s.MaxLoad = load
+			}
+		}
+
+		// Intervalos consecutivos, incluidos los vacíos
+		start := row.Time.Truncate(opts.Bucket)
+		if len(buckets) == 0 {
+			buckets = append(buckets, Bucket{Start: start, Requests: make(map[string]int)})
+		}
+		for buckets[len(buckets)-1].Start.Before(start) {
+			next := buckets[len(buckets)-1].Start.Add(opts.Bucket)
+			buckets = append(buckets, Bucket{Start: next, Requests: make(map[string]int)})
+		}
+		buckets[len(buckets)-1].Requests[row.Server]++
+		if row.Failed {
+			// Un trabajo fallido queda en el rango sin contar como asignado
+			if _, ok := counts[row.WorkID]; !ok {
+				counts[row.WorkID] = 0
+			}
+		} else {
+			counts[row.WorkID]++
+		}
+	}
+	r.Timeline = buckets
+
+	var requests []float64
+	for _, s := range stats {
+		if s.LoadSamples > 0 {
+			s.MeanLoad /= float64(s.LoadSamples)
+		}
+		s.Share = float64(s.Requests) / float64(len(data.lb))
+		r.Servers = append(r.Servers, *s)
+		requests = append(requests, float64(s.Requests))
+	}
+	sort.Slice(r.Servers, func(i, j int) bool { return r.Servers[i].Address < r.Servers[j].Address })
+	r.Fairness = jain(requests)
+	r.Imbalanced = len(requests) > 1 && r.Fairness < opts.MinFairness
+
+	// Trabajos duplicados y faltantes; el rango incluye lo que vieron los servidores
+	for _, row := range data.servers {
+		if _, ok := counts[row.WorkID]; !ok {
+			counts[row.WorkID] = 0
+		}
+	}
+	first := true
+	for id, count := range counts {
+		if first || id < r.WorkIDs.Min {
+			r.WorkIDs.Min = id
+		}
+		if first || id > r.WorkIDs.Max {
+			r.WorkIDs.Max = id
+		}
+		first = false
+		if count > 0 {
+			r.WorkIDs.Distinct++
+		}
+		if count > 1 {
+			r.WorkIDs.Duplicated++
+			r.WorkIDs.Repeats += count - 1
+			r.WorkIDs.Top = append(r.WorkIDs.Top, IDCount{WorkID: id, Count: count})
+		}
+	}
+	sort.Slice(r.WorkIDs.Top, func(i, j int) bool {
+		a, b := r.WorkIDs.Top[i], r.WorkIDs.Top[j]
+		return a.Count > b.Count || (a.Count == b.Count && a.WorkID < b.WorkID)
+	})
+	if len(r.WorkIDs.Top) > 10 {
+		r.WorkIDs.Top = r.WorkIDs.Top[:10]
+	}
+	missingRanges(counts, &r.WorkIDs)
+	return r
+}
+
+// Cuenta los work_id faltantes entre los vistos recorriendo solo los huecos,
+// en int64 para que un rango que llega a MaxInt32 no desborde
+func missingRanges(counts map[int32]int, w *WorkIDStats) {
+	ids := make([]int64, 0, len(counts))
+	for id := range counts {
+		ids = append(ids, int64(id))
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for i := 1; i < len(ids); i++ {
+		from, to := ids[i-1]+1, ids[i]-1
+		if from > to {
+			continue
+		}
+		w.Missing += to - from + 1
+		if len(w.Gaps) < maxMissingRanges {
+			w.Gaps = append(w.Gaps, IDRange{From: int32(from), To: int32(to)})
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMissingRanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		ids     []int32
+		missing int64
+		gaps    []IDRange
+	}{
+		{"sin huecos", []int32{1, 2, 3}, 0, nil},
+		{"un faltante", []int32{1, 3}, 1, []IDRange{{2, 2}}},
+		{"rangos", []int32{1, 5, 6, 10}, 6, []IDRange{{2, 4}, {7, 9}}},
+		{"hasta MaxInt32", []int32{math.MaxInt32 - 3, math.MaxInt32}, 2, []IDRange{{math.MaxInt32 - 2, math.MaxInt32 - 1}}},
+		{"rango completo de int32", []int32{math.MinInt32, math.MaxInt32}, math.MaxUint32 - 1, []IDRange{{math.MinInt32 + 1, math.MaxInt32 - 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counts := make(map[int32]int)
+			for _, id := range tt.ids {
+				counts[id] = 1
+			}
+			var w WorkIDStats
+			missingRanges(counts, &w)
+			if w.Missing != tt.missing || !reflect.DeepEqual(w.Gaps, tt.gaps) {
+				t.Errorf("faltantes %d %v, se esperaban %d %v", w.Missing, w.Gaps, tt.missing, tt.gaps)
+			}
+		})
+	}
+
+	// La lista de rangos tiene un tope aunque falten muchos
+	counts := make(map[int32]int)
+	for id := int32(0); id < 2*maxMissingRanges*2; id += 2 {
+		counts[id] = 1
+	}
+	var w WorkIDStats
+	missingRanges(counts, &w)
+	if len(w.Gaps) != maxMissingRanges || w.Missing != 2*maxMissingRanges-1 {
+		t.Errorf("%d rangos y %d faltantes", len(w.Gaps), w.Missing)
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	lb := func(sec int, id int32, server string) *lbRow {
+		return &lbRow{Time: start.Add(time.Duration(sec) * time.Second), WorkID: id, Server: server, Load: 1, Result: "ok"}
+	}
+	srv := func(sec int, id int32) *serverRow {
+		return &serverRow{Time: start.Add(time.Duration(sec) * time.Second), WorkID: id, Result: "ok"}
+	}
+	failed := func(row *lbRow) *lbRow {
+		row.Failed = true
+		return row
+	}
+	tests := []struct {
+		name       string
+		data       logData
+		fairness   float64
+		imbalanced bool
+		duplicated int
+		missing    int64
+		matched    int
+		lbOnly     int
+		serverOnly int
+		buckets    int
+	}{
+		{"parejo", logData{
+			lb:      []*lbRow{lb(0, 1, "a"), lb(1, 2, "b"), lb(2, 3, "a"), lb(3, 4, "b")},
+			servers: []*serverRow{srv(0, 1), srv(1, 2), srv(2, 3), srv(3, 4)},
+		}, 1, false, 0, 0, 4, 0, 0, 1},
+		{"desbalanceado con duplicados y huecos", logData{
+			lb:      []*lbRow{lb(0, 1, "a"), lb(1, 1, "a"), lb(2, 4, "a"), lb(20, 5, "b")},
+			servers: []*serverRow{srv(0, 1), srv(60, 9)},
+		}, 1.0 * 16 / (2 * 10), true, 1, 5, 1, 3, 1, 3},
+		{"fallidas sin emparejar", logData{
+			lb:      []*lbRow{lb(0, 1, "a"), failed(lb(1, 2, "b")), failed(lb(2, 2, "b"))},
+			servers: []*serverRow{srv(0, 1)},
+		}, 1.0 * 9 / (2 * 5), false, 0, 0, 1, 0, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := tt.data
+			r := analyze(&data, Options{Bucket: 10 * time.Second, Tolerance: time.Second, MinFairness: 0.9})
+			if math.Abs(r.Fairness-tt.fairness) > 1e-9 || r.Imbalanced != tt.imbalanced {
+				t.Errorf("equidad %.3f (desbalance %v), se esperaba %.3f (%v)", r.Fairness, r.Imbalanced, tt.fairness, tt.imbalanced)
+			}
+			if r.WorkIDs.Duplicated != tt.duplicated || r.WorkIDs.Missing != tt.missing {
+				t.Errorf("duplicados %d, faltantes %d; se esperaban %d y %d", r.WorkIDs.Duplicated, r.WorkIDs.Missing, tt.duplicated, tt.missing)
+			}
+			c := r.Consistency
+			if c.Matched != tt.matched || c.LBOnly != tt.lbOnly || c.ServerOnly != tt.serverOnly {
+				t.Errorf("consistencia %+v", c)
+			}
+			if len(r.Timeline) != tt.buckets {
+				t.Errorf("%d intervalos, se esperaban %d", len(r.Timeline), tt.buckets)
+			}
+		})
+	}
+}
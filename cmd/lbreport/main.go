@@ -0,0 +1,163 @@
+// lbreport analiza los CSV de respuestas del balanceador y de los servidores
+// (incluido responses.csv con ambos esquemas mezclados): reparto por servidor
+// en el tiempo, carga al asignar, equidad, trabajos duplicados o faltantes y
+// correspondencia entre lo que registró el balanceador y lo que procesaron
+// los servidores.
+//
+// Ejemplo:
+//
+//	lbreport -bucket 30s responses.csv
+//	lbreport -json report.json responses.csv
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+func main() {
+	log.SetFlags(0)
+	opts := Options{}
+	flag.DurationVar(&opts.Bucket, "bucket", time.Minute, "ancho de los intervalos de la serie temporal")
+	flag.DurationVar(&opts.Tolerance, "tolerance", 2*time.Second, "diferencia máxima entre la fila del balanceador y la del servidor para emparejarlas")
+	flag.Float64Var(&opts.MinFairness, "min-fairness", 0.9, "índice de Jain por debajo del cual se marca desbalance")
+	jsonFile := flag.String("json", "", "escribe el informe en JSON en este archivo (- = salida estándar, sin tabla)")
+	flag.Parse()
+
+	if opts.Bucket <= 0 {
+		log.Fatalf("Error: -bucket debe ser positivo")
+	}
+	files := flag.Args()
+	if len(files) == 0 {
+		files = []string{"responses.csv"}
+	}
+
+	data, err := readLogs(files)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	report := analyze(data, opts)
+	report.Files = files
+
+	if *jsonFile != "" {
+		if err := writeJSON(report, *jsonFile); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		if *jsonFile == "-" {
+			return
+		}
+	}
+	printReport(report)
+}
+
+// Escribe el informe en JSON
+func writeJSON(r *Report, filename string) error {
+	out := os.Stdout
+	if filename != "-" {
+		file, err := os.Create(filename)
+		if err != nil {
+			return fmt.Errorf("error al crear el informe JSON: %v", err)
+		}
+		defer file.Close()
+		out = file
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r); err != nil {
+		return fmt.Errorf("error al escribir el informe JSON: %v", err)
+	}
+	return nil
+}
+
+// Descripción del origen de la carga al asignar
+var loadSources = map[string]string{
+	"balancer": "registrada por el balanceador",
+	"server":   "aproximada con la carga del servidor al terminar",
+	"none":     "no disponible",
+}
+
+func printReport(r *Report) {
+	fmt.Printf("Archivos: %s\n", strings.Join(r.Files, ", "))
+	fmt.Printf("Filas del balanceador: %d, de los servidores: %d, descartadas: %d\n", r.LBRows, r.ServerRows, r.Skipped)
+	if r.LBRows == 0 {
+		fmt.Println("No hay filas del balanceador para analizar")
+		return
+	}
+	fmt.Printf("Periodo: %s - %s (%v)\n\n", r.Start.Format(lbTimeLayout), r.End.Format(lbTimeLayout), r.End.Sub(r.Start))
+
+	// Reparto por servidor
+	fmt.Printf("Carga al asignar: %s\n", loadSources[r.LoadSource])
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "Servidor\tsolicitudes\terrores\tporcentaje\tcarga media\tcarga máx\t")
+	for _, s := range r.Servers {
+		mean, max := "-", "-"
+		if s.LoadSamples > 0 {
+			mean, max = fmt.Sprintf("%.2f", s.MeanLoad), fmt.Sprintf("%d", s.MaxLoad)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f%%\t%s\t%s\t\n", s.Address, s.Requests, s.Errors, 100*s.Share, mean, max)
+	}
+	tw.Flush()
+
+	verdict := "equilibrado"
+	if r.Imbalanced {
+		verdict = "DESBALANCEADO"
+	}
+	fmt.Printf("\nÍndice de Jain: %.3f (%s)\n\n", r.Fairness, verdict)
+
+	// Serie temporal
+	tw = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(tw, "Intervalo\t")
+	for _, s := range r.Servers {
+		fmt.Fprintf(tw, "%s\t", s.Address)
+	}
+	fmt.Fprintln(tw, "total\t")
+	for _, b := range r.Timeline {
+		fmt.Fprintf(tw, "%s\t", b.Start.Format("15:04:05"))
+		total := 0
+		for _, s := range r.Servers {
+			fmt.Fprintf(tw, "%d\t", b.Requests[s.Address])
+			total += b.Requests[s.Address]
+		}
+		fmt.Fprintf(tw, "%d\t\n", total)
+	}
+	tw.Flush()
+
+	// Trabajos
+	w := r.WorkIDs
+	fmt.Printf("\nTrabajos: rango %d-%d, distintos: %d, duplicados: %d (%d asignaciones repetidas), faltantes: %d\n",
+		w.Min, w.Max, w.Distinct, w.Duplicated, w.Repeats, w.Missing)
+	if len(w.Top) > 0 {
+		var top []string
+		for _, c := range w.Top {
+			top = append(top, fmt.Sprintf("%d (x%d)", c.WorkID, c.Count))
+		}
+		fmt.Printf("Más repetidos: %s\n", strings.Join(top, ", "))
+	}
+	if len(w.Gaps) > 0 {
+		var missing []string
+		for i, gap := range w.Gaps {
+			if i == 20 {
+				missing = append(missing, "...")
+				break
+			}
+			if gap.From == gap.To {
+				missing = append(missing, fmt.Sprintf("%d", gap.From))
+			} else {
+				missing = append(missing, fmt.Sprintf("%d-%d", gap.From, gap.To))
+			}
+		}
+		fmt.Printf("Faltantes: %s\n", strings.Join(missing, ", "))
+	}
+
+	// Consistencia de extremo a extremo
+	c := r.Consistency
+	fmt.Printf("\nConsistencia: %.1f%% de las filas del balanceador sin error tienen fila del servidor\n", 100*c.Ratio)
+	fmt.Printf("Emparejadas: %d, con error: %d, solo balanceador: %d, solo servidor: %d, resultado distinto: %d\n",
+		c.Matched, c.Failed, c.LBOnly, c.ServerOnly, c.ResultMismatch)
+}
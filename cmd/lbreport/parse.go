@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formatos de fecha de cada registro
+const (
+	lbTimeLayout     = "2006/01/02 15:04:05" // Balanceador
+	serverTimeLayout = "2006-01-02 15:04:05" // Servidores
+)
+
+// Fila escrita por el balanceador: Timestamp,TrabajoID,Servidor[,Carga],Resultado[,Ruta,Pool[,Estado]]
+type lbRow struct {
+	Time    time.Time
+	WorkID  int32
+	Server  string
+	Load    int32 // -1 si la fila no registra la carga (formato anterior)
+	Result  string
+	Failed  bool       // El balanceador devolvió un error al cliente (Estado distinto de OK)
+	matched *serverRow // Fila del servidor correspondiente (nil = sin emparejar)
+}
+
+// Fila escrita por un servidor: Timestamp,TrabajoID,Resultado,CargaActiva
+type serverRow struct {
+	Time    time.Time
+	WorkID  int32
+	Result  string
+	Load    int32
+	matched bool
+}
+
+// Contenido de los archivos leídos
+type logData struct {
+	lb      []*lbRow
+	servers []*serverRow
+	skipped int // Filas que no se pudieron interpretar
+}
+
+// Lee uno o más CSV; cada archivo puede mezclar filas del balanceador y de los servidores
+func readLogs(filenames []string) (*logData, error) {
+	data := &logData{}
+	for _, filename := range filenames {
+		if err := data.readFile(filename); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+func (d *logData) readFile(filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("error al abrir el archivo CSV: %v", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1 // El esquema cambia de una fila a otra
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			// Filas cortadas por escrituras concurrentes: se cuentan y se sigue
+			if _, ok := err.(*csv.ParseError); ok {
+				d.skipped++
+				continue
+			}
+			return fmt.Errorf("error al leer %s: %v", filename, err)
+		}
+		if len(record) > 0 && record[0] == "Timestamp" {
+			continue // Encabezado
+		}
+		if !d.parseRecord(record) {
+			d.skipped++
+		}
+	}
+}
+
+// Clasifica la fila según el formato de fecha y la posición del resultado
+func (d *logData) parseRecord(record []string) bool {
+	if len(record) < 4 {
+		return false
+	}
+	id, err := strconv.ParseInt(strings.TrimSpace(record[1]), 10, 32)
+	if err != nil {
+		return false
+	}
+
+	if t, err := time.ParseInLocation(lbTimeLayout, record[0], time.Local); err == nil {
+		row := &lbRow{Time: t, WorkID: int32(id), Server: record[2], Load: -1, Result: record[len(record)-1]}
+		if len(record) >= 7 {
+			row.Result = record[4]
+		}
+		if len(record) >= 8 {
+			row.Failed = record[7] != "OK"
+		}
+		if len(record) >= 5 {
+			load, err := strconv.ParseInt(record[3], 10, 32)
+			if err != nil {
+				return false
+			}
+			row.Load = int32(load)
+		}
+		d.lb = append(d.lb, row)
+		return true
+	}
+
+	if t, err := time.ParseInLocation(serverTimeLayout, record[0], time.Local); err == nil {
+		load, err := strconv.ParseInt(record[3], 10, 32)
+		if err != nil {
+			return false
+		}
+		d.servers = append(d.servers, &serverRow{Time: t, WorkID: int32(id), Result: record[2], Load: int32(load)})
+		return true
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+// lbsim simula en tiempo virtual un clúster de servidores para comparar las
+// estrategias de balanceo del paquete balancer antes de usarlas en selectServer.
+//
+// Ejemplo:
+//
+//	lbsim -servers 10 -speeds 1,1,0.5,2 -rps 300 -probe-delay 2ms -refresh 100ms
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"Distributed_load_balancer/balancer"
+)
+
+// Convierte una lista separada por comas en factores de velocidad
+func parseSpeeds(list string) ([]float64, error) {
+	var speeds []float64
+	for _, part := range strings.Split(list, ",") {
+		speed, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || speed <= 0 {
+			return nil, fmt.Errorf("factor de velocidad inválido: %q", part)
+		}
+		speeds = append(speeds, speed)
+	}
+	return speeds, nil
+}
+
+func main() {
+	log.SetFlags(0)
+	cfg := &Config{}
+	flag.IntVar(&cfg.Servers, "servers", 10, "número de servidores")
+	speeds := flag.String("speeds", "1", "factores de velocidad separados por comas, asignados en ciclo")
+	flag.IntVar(&cfg.Workers, "workers", 4, "solicitudes simultáneas por servidor (0 = sin límite)")
+	flag.StringVar(&cfg.Service.Name, "service", "exponential", "distribución del tiempo de servicio: fixed, uniform, exponential o lognormal")
+	flag.DurationVar(&cfg.Service.Mean, "mean", 100*time.Millisecond, "tiempo medio de servicio")
+	flag.DurationVar(&cfg.Service.Min, "min", 50*time.Millisecond, "tiempo mínimo de servicio (uniform)")
+	flag.DurationVar(&cfg.Service.Max, "max", 150*time.Millisecond, "tiempo máximo de servicio (uniform)")
+	flag.Float64Var(&cfg.Service.Sigma, "sigma", 0.5, "desviación del logaritmo del tiempo de servicio (lognormal)")
+	flag.Float64Var(&cfg.RPS, "rps", 300, "tasa de llegadas en solicitudes por segundo")
+	flag.StringVar(&cfg.Arrivals, "arrivals", "poisson", "proceso de llegadas: poisson o constant")
+	flag.IntVar(&cfg.Requests, "requests", 20000, "número de solicitudes a simular")
+	flag.DurationVar(&cfg.ProbeDelay, "probe-delay", 0, "retardo entre consultar la carga y que la solicitud llegue al servidor")
+	flag.DurationVar(&cfg.Refresh, "refresh", 0, "intervalo de consulta de carga en segundo plano (0 = consultar en cada solicitud)")
+	flag.BoolVar(&cfg.ReportCapacity, "report-capacity", true, "los servidores reportan capacidad y utilización además de la carga")
+	flag.Int64Var(&cfg.Seed, "seed", 1, "semilla de la simulación")
+	strategyList := flag.String("strategies", strings.Join(balancer.Names(), ","), "estrategias a comparar separadas por comas")
+	flag.Parse()
+
+	var err error
+	if cfg.Speeds, err = parseSpeeds(*speeds); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	w := generateWorkload(cfg)
+	fmt.Printf("Simulando %d solicitudes a %.1f rps (%s) sobre %d servidores, servicio %s medio %v, consulta %v, refresco %v\n\n",
+		cfg.Requests, cfg.RPS, cfg.Arrivals, cfg.Servers, cfg.Service.Name, cfg.Service.Mean, cfg.ProbeDelay, cfg.Refresh)
+
+	var results []*Result
+	for _, name := range strings.Split(*strategyList, ",") {
+		strategy, err := balancer.New(strings.TrimSpace(name), rand.New(rand.NewSource(cfg.Seed)))
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		results = append(results, simulate(cfg, w, strategy))
+	}
+
+	printSummary(results)
+	for _, r := range results {
+		printServers(r)
+	}
+}
+
+func fmtMs(d time.Duration) string {
+	return fmt.Sprintf("%.1f", float64(d)/float64(time.Millisecond))
+}
+
+// Tabla comparativa de latencias y equidad por estrategia
+func printSummary(results []*Result) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "Estrategia\tmedia ms\tp50 ms\tp90 ms\tp99 ms\tp99.9 ms\tmax ms\tJain\t")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%.3f\t\n", r.Strategy,
+			fmtMs(r.Mean()), fmtMs(r.Quantile(0.5)), fmtMs(r.Quantile(0.9)), fmtMs(r.Quantile(0.99)),
+			fmtMs(r.Quantile(0.999)), fmtMs(r.Quantile(1)), r.Fairness())
+	}
+	tw.Flush()
+}
+
+// Tabla de solicitudes y utilización por servidor
+func printServers(r *Result) {
+	fmt.Printf("\n%s:\n", r.Strategy)
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "Servidor\tvelocidad\tsolicitudes\tutilización\t")
+	for _, srv := range r.Servers {
+		fmt.Fprintf(tw, "%s\t%.2f\t%d\t%.1f%%\t\n", srv.address, srv.speed, srv.requests, 100*r.Utilization(srv))
+	}
+	tw.Flush()
+}
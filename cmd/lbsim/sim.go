@@ -0,0 +1,356 @@
+package main
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"Distributed_load_balancer/balancer"
+)
+
+// Tipos de evento de la simulación
+const (
+	evArrival   = iota // Llega una solicitud al balanceador
+	evDispatch         // La solicitud llega al servidor elegido
+	evDeparture        // El servidor termina una solicitud
+	evRefresh          // El balanceador consulta la carga de todos los servidores
+	evSnapshot         // La carga consultada queda visible para el balanceador
+)
+
+type event struct {
+	at       time.Duration
+	seq      int // Desempate para que el orden sea determinista
+	kind     int
+	req      *simRequest
+	snapshot []balancer.Candidate
+}
+
+// Cola de eventos ordenada por tiempo virtual
+type eventQueue []*event
+
+func (q eventQueue) Len() int { return len(q) }
+func (q eventQueue) Less(i, j int) bool {
+	if q[i].at != q[j].at {
+		return q[i].at < q[j].at
+	}
+	return q[i].seq < q[j].seq
+}
+func (q eventQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *eventQueue) Push(x interface{}) { *q = append(*q, x.(*event)) }
+func (q *eventQueue) Pop() interface{} {
+	old := *q
+	e := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return e
+}
+
+// Solicitud simulada
+type simRequest struct {
+	arrival time.Duration
+	base    time.Duration // Tiempo de servicio con velocidad 1
+	server  int
+}
+
+// Servidor simulado con un número fijo de trabajadores y una cola FIFO
+type simServer struct {
+	address  string
+	speed    float64
+	workers  int // 0 = sin límite
+	active   int
+	queue    []*simRequest
+	busy     float64 // Integral de trabajadores ocupados en el tiempo (segundos)
+	last     time.Duration
+	requests int
+}
+
+// Acumula el tiempo ocupado hasta el instante t
+func (s *simServer) advance(t time.Duration) {
+	s.busy += float64(s.active) * (t - s.last).Seconds()
+	s.last = t
+}
+
+// Carga que reportaría el servidor
+func (s *simServer) candidate(reportCapacity bool) balancer.Candidate {
+	c := balancer.Candidate{Address: s.address, Load: int32(s.active), QueueDepth: int32(len(s.queue))}
+	if reportCapacity && s.workers > 0 {
+		c.Capacity = int32(s.workers)
+		c.Utilization = float64(s.active+len(s.queue)) / float64(s.workers)
+	}
+	return c
+}
+
+// Parámetros de la simulación
+type Config struct {
+	Servers        int
+	Speeds         []float64
+	Workers        int
+	Service        Distribution
+	RPS            float64
+	Arrivals       string // poisson o constant
+	Requests       int
+	ProbeDelay     time.Duration // Tiempo entre consultar la carga y que la solicitud llegue al servidor
+	Refresh        time.Duration // Intervalo de consulta en segundo plano (0 = consultar en cada solicitud)
+	ReportCapacity bool
+	Seed           int64
+}
+
+// Comprueba que la configuración sea válida antes de generar la carga
+func (cfg *Config) Validate() error {
+	if cfg.Servers < 1 || cfg.Requests < 1 || cfg.RPS <= 0 {
+		return fmt.Errorf("se necesitan servidores, solicitudes y una tasa positivos")
+	}
+	if len(cfg.Speeds) == 0 {
+		return fmt.Errorf("se necesita al menos un factor de velocidad")
+	}
+	for _, speed := range cfg.Speeds {
+		if speed <= 0 {
+			return fmt.Errorf("el factor de velocidad debe ser positivo: %v", speed)
+		}
+	}
+	if cfg.Workers < 0 {
+		return fmt.Errorf("los trabajadores no pueden ser negativos: %d", cfg.Workers)
+	}
+	if cfg.ProbeDelay < 0 || cfg.Refresh < 0 {
+		return fmt.Errorf("el retardo de consulta (%v) y el refresco (%v) no pueden ser negativos", cfg.ProbeDelay, cfg.Refresh)
+	}
+	switch cfg.Arrivals {
+	case "poisson", "constant":
+	default:
+		return fmt.Errorf("proceso de llegadas desconocido: %s", cfg.Arrivals)
+	}
+	return cfg.Service.Validate()
+}
+
+// Distribución de tiempos de servicio
+type Distribution struct {
+	Name     string // fixed, uniform, exponential o lognormal
+	Mean     time.Duration
+	Min, Max time.Duration
+	Sigma    float64
+}
+
+// Comprueba que los parámetros de la distribución sean válidos
+func (d Distribution) Validate() error {
+	switch d.Name {
+	case "fixed", "exponential":
+		if d.Mean < 0 {
+			return fmt.Errorf("el tiempo medio no puede ser negativo: %v", d.Mean)
+		}
+	case "lognormal":
+		if d.Mean <= 0 {
+			return fmt.Errorf("el tiempo medio de lognormal debe ser positivo: %v", d.Mean)
+		}
+		if d.Sigma < 0 {
+			return fmt.Errorf("la desviación no puede ser negativa: %v", d.Sigma)
+		}
+	case "uniform":
+		if d.Min < 0 {
+			return fmt.Errorf("el mínimo no puede ser negativo: %v", d.Min)
+		}
+		if d.Max < d.Min {
+			return fmt.Errorf("el máximo (%v) no puede ser menor que el mínimo (%v)", d.Max, d.Min)
+		}
+	default:
+		return fmt.Errorf("distribución desconocida: %s", d.Name)
+	}
+	return nil
+}
+
+func (d Distribution) Sample(rng *rand.Rand) time.Duration {
+	switch d.Name {
+	case "fixed":
+		return d.Mean
+	case "uniform":
+		return d.Min + time.Duration(rng.Float64()*float64(d.Max-d.Min))
+	case "exponential":
+		return time.Duration(rng.ExpFloat64() * float64(d.Mean))
+	case "lognormal":
+		mu := math.Log(float64(d.Mean)) - d.Sigma*d.Sigma/2
+		return time.Duration(math.Exp(mu + d.Sigma*rng.NormFloat64()))
+	}
+	panic(fmt.Sprintf("distribución desconocida: %s", d.Name))
+}
+
+// Carga de trabajo generada una sola vez para que todas las estrategias vean la misma
+type workload struct {
+	arrivals []time.Duration
+	bases    []time.Duration
+}
+
+func generateWorkload(cfg *Config) *workload {
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	w := &workload{}
+	var t time.Duration
+	for i := 0; i < cfg.Requests; i++ {
+		w.arrivals = append(w.arrivals, t)
+		w.bases = append(w.bases, cfg.Service.Sample(rng))
+		if cfg.Arrivals == "constant" {
+			t += time.Duration(float64(time.Second) / cfg.RPS)
+		} else {
+			t += time.Duration(rng.ExpFloat64() / cfg.RPS * float64(time.Second))
+		}
+	}
+	return w
+}
+
+// Resultado de simular una estrategia
+type Result struct {
+	Strategy  string
+	Latencies []time.Duration // Ordenadas
+	Servers   []*simServer
+	Elapsed   time.Duration
+}
+
+// Simulador de eventos discretos en tiempo virtual
+type simulator struct {
+	cfg      *Config
+	strategy balancer.Strategy
+	servers  []*simServer
+	events   eventQueue
+	seq      int
+	now      time.Duration
+	visible  []balancer.Candidate // Última carga visible para el balanceador
+	pending  int                  // Solicitudes sin terminar
+	latency  []time.Duration
+}
+
+func (s *simulator) schedule(e *event) {
+	s.seq++
+	e.seq = s.seq
+	heap.Push(&s.events, e)
+}
+
+// Estado actual de todos los servidores
+func (s *simulator) snapshot() []balancer.Candidate {
+	candidates := make([]balancer.Candidate, len(s.servers))
+	for i, srv := range s.servers {
+		candidates[i] = srv.candidate(s.cfg.ReportCapacity)
+	}
+	return candidates
+}
+
+// Inicia una solicitud en el servidor o la encola
+func (s *simulator) start(srv *simServer, req *simRequest) {
+	srv.advance(s.now)
+	if srv.workers > 0 && srv.active >= srv.workers {
+		srv.queue = append(srv.queue, req)
+		return
+	}
+	srv.active++
+	service := time.Duration(float64(req.base) / srv.speed)
+	s.schedule(&event{at: s.now + service, kind: evDeparture, req: req})
+}
+
+// Ejecuta la simulación completa con la estrategia dada
+func simulate(cfg *Config, w *workload, strategy balancer.Strategy) *Result {
+	s := &simulator{cfg: cfg, strategy: strategy, pending: len(w.arrivals)}
+	for i := 0; i < cfg.Servers; i++ {
+		s.servers = append(s.servers, &simServer{
+			address: fmt.Sprintf("server-%02d", i+1),
+			speed:   cfg.Speeds[i%len(cfg.Speeds)],
+			workers: cfg.Workers,
+		})
+	}
+	s.visible = s.snapshot()
+
+	for i := range w.arrivals {
+		s.schedule(&event{at: w.arrivals[i], kind: evArrival, req: &simRequest{arrival: w.arrivals[i], base: w.bases[i]}})
+	}
+	if cfg.Refresh > 0 {
+		s.schedule(&event{at: 0, kind: evRefresh})
+	}
+
+	for s.events.Len() > 0 && s.pending > 0 {
+		e := heap.Pop(&s.events).(*event)
+		s.now = e.at
+		switch e.kind {
+		case evArrival:
+			// Con consulta en cada solicitud, la carga se lee ahora y la solicitud
+			// llega al servidor tras el retardo de la consulta
+			candidates := s.visible
+			delay := time.Duration(0)
+			if cfg.Refresh == 0 {
+				candidates = s.snapshot()
+				delay = cfg.ProbeDelay
+			}
+			e.req.server = s.strategy.Pick(candidates)
+			s.schedule(&event{at: s.now + delay, kind: evDispatch, req: e.req})
+		case evDispatch:
+			srv := s.servers[e.req.server]
+			srv.requests++
+			s.start(srv, e.req)
+		case evDeparture:
+			srv := s.servers[e.req.server]
+			srv.advance(s.now)
+			srv.active--
+			s.latency = append(s.latency, s.now-e.req.arrival)
+			s.pending--
+			if len(srv.queue) > 0 {
+				next := srv.queue[0]
+				srv.queue = srv.queue[1:]
+				s.start(srv, next)
+			}
+		case evRefresh:
+			s.schedule(&event{at: s.now + cfg.ProbeDelay, kind: evSnapshot, snapshot: s.snapshot()})
+			s.schedule(&event{at: s.now + cfg.Refresh, kind: evRefresh})
+		case evSnapshot:
+			s.visible = e.snapshot
+		}
+	}
+
+	for _, srv := range s.servers {
+		srv.advance(s.now)
+	}
+	sort.Slice(s.latency, func(i, j int) bool { return s.latency[i] < s.latency[j] })
+	return &Result{Strategy: strategy.Name(), Latencies: s.latency, Servers: s.servers, Elapsed: s.now}
+}
+
+// Latencia en el cuantil q
+func (r *Result) Quantile(q float64) time.Duration {
+	if len(r.Latencies) == 0 {
+		return 0
+	}
+	i := int(math.Ceil(q*float64(len(r.Latencies)))) - 1
+	return r.Latencies[max(0, min(i, len(r.Latencies)-1))]
+}
+
+func (r *Result) Mean() time.Duration {
+	if len(r.Latencies) == 0 {
+		return 0
+	}
+	var sum time.Duration
+	for _, l := range r.Latencies {
+		sum += l
+	}
+	return sum / time.Duration(len(r.Latencies))
+}
+
+// Utilización de un servidor: fracción del tiempo que sus trabajadores estuvieron
+// ocupados (o concurrencia media si no tiene límite de trabajadores)
+func (r *Result) Utilization(srv *simServer) float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	u := srv.busy / r.Elapsed.Seconds()
+	if srv.workers > 0 {
+		u /= float64(srv.workers)
+	}
+	return u
+}
+
+// Índice de equidad de Jain sobre la utilización de los servidores
+func (r *Result) Fairness() float64 {
+	var sum, squares float64
+	for _, srv := range r.Servers {
+		u := r.Utilization(srv)
+		sum += u
+		squares += u * u
+	}
+	if squares == 0 {
+		return 1
+	}
+	return sum * sum / (float64(len(r.Servers)) * squares)
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"container/heap"
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+
+	"Distributed_load_balancer/balancer"
+)
+
+func TestDistributionValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		d       Distribution
+		wantErr bool
+	}{
+		{"fixed", Distribution{Name: "fixed", Mean: time.Millisecond}, false},
+		{"exponencial", Distribution{Name: "exponential", Mean: time.Millisecond}, false},
+		{"uniforme de ancho cero", Distribution{Name: "uniform", Min: time.Millisecond, Max: time.Millisecond}, false},
+		{"lognormal", Distribution{Name: "lognormal", Mean: time.Millisecond, Sigma: 0.5}, false},
+		{"desconocida", Distribution{Name: "pareto", Mean: time.Millisecond}, true},
+		{"uniforme invertida", Distribution{Name: "uniform", Min: 2 * time.Millisecond, Max: time.Millisecond}, true},
+		{"uniforme negativa", Distribution{Name: "uniform", Min: -time.Millisecond, Max: time.Millisecond}, true},
+		{"media negativa", Distribution{Name: "exponential", Mean: -time.Millisecond}, true},
+		{"lognormal sin media", Distribution{Name: "lognormal", Sigma: 0.5}, true},
+		{"lognormal con desviación negativa", Distribution{Name: "lognormal", Mean: time.Millisecond, Sigma: -1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.d.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, se esperaba error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// Configuración válida sobre la que cada caso cambia un campo
+func testConfig() *Config {
+	return &Config{
+		Servers:  2,
+		Speeds:   []float64{1},
+		Workers:  1,
+		Service:  Distribution{Name: "fixed", Mean: 10 * time.Millisecond},
+		RPS:      100,
+		Arrivals: "constant",
+		Requests: 10,
+		Seed:     1,
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(*Config)
+		wantErr bool
+	}{
+		{"válida", func(*Config) {}, false},
+		{"poisson sin límite de trabajadores", func(c *Config) { c.Arrivals, c.Workers = "poisson", 0 }, false},
+		{"sin servidores", func(c *Config) { c.Servers = 0 }, true},
+		{"sin solicitudes", func(c *Config) { c.Requests = 0 }, true},
+		{"sin tasa", func(c *Config) { c.RPS = 0 }, true},
+		{"sin velocidades", func(c *Config) { c.Speeds = nil }, true},
+		{"velocidad nula", func(c *Config) { c.Speeds = []float64{1, 0} }, true},
+		{"trabajadores negativos", func(c *Config) { c.Workers = -1 }, true},
+		{"retardo negativo", func(c *Config) { c.ProbeDelay = -time.Millisecond }, true},
+		{"refresco negativo", func(c *Config) { c.Refresh = -time.Millisecond }, true},
+		{"llegadas desconocidas", func(c *Config) { c.Arrivals = "ráfagas" }, true},
+		{"distribución inválida", func(c *Config) { c.Service = Distribution{Name: "uniform", Min: 2, Max: 1} }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			tt.change(cfg)
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, se esperaba error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEventQueueOrder(t *testing.T) {
+	var q eventQueue
+	// Mismo instante: sale primero el de menor seq aunque se agregue después
+	for _, e := range []*event{{at: 20, seq: 1}, {at: 10, seq: 4}, {at: 10, seq: 2}, {at: 0, seq: 5}, {at: 10, seq: 3}} {
+		heap.Push(&q, e)
+	}
+	var got []int
+	for q.Len() > 0 {
+		got = append(got, heap.Pop(&q).(*event).seq)
+	}
+	if want := []int{5, 2, 3, 4, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("orden %v, se esperaba %v", got, want)
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	tests := []struct {
+		name         string
+		strategy     string
+		change       func(*Config)
+		wantRequests []int
+		wantMax      time.Duration // Latencia máxima
+	}{
+		// Una llegada cada 10 ms y 10 ms de servicio: la llegada a los 10 ms se
+		// programó antes que la salida del mismo instante, así que el balanceador
+		// todavía ve ocupado al primer servidor y elige el segundo
+		{"least-load alterna", "least-load", func(*Config) {}, []int{5, 5}, 10 * time.Millisecond},
+		{"round-robin alterna", "round-robin", func(*Config) {}, []int{5, 5}, 10 * time.Millisecond},
+		// Con llegadas cada 5 ms cada servidor recibe una cada 10 ms y no hay cola
+		{"round-robin al doble de tasa", "round-robin", func(c *Config) { c.RPS = 200 }, []int{5, 5}, 10 * time.Millisecond},
+		// El segundo servidor tarda 40 ms: el turno rotativo le sigue enviando la
+		// mitad y la cola crece 20 ms por solicitud; least-load solo lo usa cuando
+		// está libre y el rápido ocupado
+		{"round-robin con un servidor lento", "round-robin", func(c *Config) { c.Speeds = []float64{1, 0.25} }, []int{5, 5}, 120 * time.Millisecond},
+		{"least-load con un servidor lento", "least-load", func(c *Config) { c.Speeds = []float64{1, 0.25} }, []int{8, 2}, 40 * time.Millisecond},
+		// Consultando la carga cada 100 ms el balanceador ve a los dos libres y
+		// siempre elige el primero
+		{"least-load con carga vencida", "least-load", func(c *Config) { c.Refresh = 100 * time.Millisecond }, []int{10, 0}, 10 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			tt.change(cfg)
+			if err := cfg.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			strategy, err := balancer.New(tt.strategy, rand.New(rand.NewSource(cfg.Seed)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := simulate(cfg, generateWorkload(cfg), strategy)
+			var requests []int
+			for _, srv := range r.Servers {
+				requests = append(requests, srv.requests)
+			}
+			if !reflect.DeepEqual(requests, tt.wantRequests) {
+				t.Errorf("solicitudes por servidor %v, se esperaban %v", requests, tt.wantRequests)
+			}
+			if len(r.Latencies) != cfg.Requests {
+				t.Fatalf("%d latencias, se esperaban %d", len(r.Latencies), cfg.Requests)
+			}
+			if r.Latencies[0] != 10*time.Millisecond || r.Quantile(1) != tt.wantMax {
+				t.Errorf("latencias entre %v y %v, se esperaba entre 10ms y %v", r.Latencies[0], r.Quantile(1), tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestSimulateDeterministic(t *testing.T) {
+	cfg := &Config{
+		Servers:        4,
+		Speeds:         []float64{1, 0.5},
+		Workers:        2,
+		Service:        Distribution{Name: "lognormal", Mean: 20 * time.Millisecond, Sigma: 0.5},
+		RPS:            200,
+		Arrivals:       "poisson",
+		Requests:       500,
+		ProbeDelay:     time.Millisecond,
+		ReportCapacity: true,
+		Seed:           7,
+	}
+	run := func(seed int64) *Result {
+		cfg := *cfg
+		cfg.Seed = seed
+		strategy, err := balancer.New("random", rand.New(rand.NewSource(seed)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return simulate(&cfg, generateWorkload(&cfg), strategy)
+	}
+	a, b := run(7), run(7)
+	if !reflect.DeepEqual(a.Latencies, b.Latencies) || a.Elapsed != b.Elapsed {
+		t.Errorf("la misma semilla dio resultados distintos: p99 %v y %v", a.Quantile(0.99), b.Quantile(0.99))
+	}
+	if c := run(8); reflect.DeepEqual(a.Latencies, c.Latencies) {
+		t.Errorf("semillas distintas dieron las mismas latencias")
+	}
+}
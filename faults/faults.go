@@ -0,0 +1,196 @@
+// Package faults inyecta fallas configurables (latencia, errores, bloqueos,
+// conexiones cerradas y cargas falsas) en servidores gRPC para probar la
+// resiliencia del balanceador.
+package faults
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	pb "Distributed_load_balancer/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Lee una configuración de fallas en formato JSON
+func LoadFile(filename string) (*pb.FaultConfig, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error al leer el archivo de fallas: %v", err)
+	}
+	cfg := &pb.FaultConfig{}
+	if err := protojson.Unmarshal(content, cfg); err != nil {
+		return nil, fmt.Errorf("error al interpretar el archivo de fallas: %v", err)
+	}
+	return cfg, nil
+}
+
+// Inyector de fallas con reglas modificables en tiempo de ejecución
+type Injector struct {
+	mu       sync.Mutex
+	name     string
+	config   *pb.FaultConfig
+	listener *Listener
+	flaps    map[*pb.FaultRule]bool // Último valor reportado por las reglas flap_load
+}
+
+// Crea un inyector; listener permite cerrar conexiones y puede ser nil
+func NewInjector(name string, listener *Listener) *Injector {
+	return &Injector{
+		name:     name,
+		config:   &pb.FaultConfig{},
+		listener: listener,
+		flaps:    make(map[*pb.FaultRule]bool),
+	}
+}
+
+// Reemplaza las reglas activas
+func (in *Injector) Set(cfg *pb.FaultConfig) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.config = proto.Clone(cfg).(*pb.FaultConfig)
+	in.flaps = make(map[*pb.FaultRule]bool)
+	log.Printf("[Fallas %s] %d reglas activas", in.name, len(in.config.Rules))
+}
+
+// Copia de las reglas activas
+func (in *Injector) Config() *pb.FaultConfig {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	return proto.Clone(in.config).(*pb.FaultConfig)
+}
+
+// Nombre corto del método a partir del nombre completo
+func shortMethod(fullMethod string) string {
+	return fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+}
+
+// Indica si la regla aplica a la llamada
+func matches(rule *pb.FaultRule, method string, req interface{}) bool {
+	if rule.Method != "" && rule.Method != method {
+		return false
+	}
+	if rule.MinWorkId != 0 || rule.MaxWorkId != 0 {
+		r, ok := req.(*pb.Request)
+		if !ok || r.WorkId < rule.MinWorkId || (rule.MaxWorkId != 0 && r.WorkId > rule.MaxWorkId) {
+			return false
+		}
+	}
+	return rule.Probability <= 0 || rand.Float64() < rule.Probability
+}
+
+// Reglas que aplican a la llamada; outbound elige las reglas de las llamadas
+// reenviadas a backend en lugar de las recibidas
+func (in *Injector) match(method string, req interface{}, outbound bool, backend string) []*pb.FaultRule {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	var rules []*pb.FaultRule
+	for _, rule := range in.config.Rules {
+		if rule.Outbound != outbound || (outbound && rule.Backend != "" && rule.Backend != backend) {
+			continue
+		}
+		if matches(rule, method, req) {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// Decide si la regla reporta la carga falsa en esta llamada
+func (in *Injector) lieAboutLoad(rule *pb.FaultRule) bool {
+	if !rule.FlapLoad {
+		return true
+	}
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.flaps[rule] = !in.flaps[rule]
+	return in.flaps[rule]
+}
+
+// Aplica las fallas previas a la llamada; drop cierra la conexión
+func (in *Injector) inject(ctx context.Context, method string, rules []*pb.FaultRule, drop func()) error {
+	for _, rule := range rules {
+		if rule.DelayMs > 0 {
+			select {
+			case <-time.After(time.Duration(rule.DelayMs) * time.Millisecond):
+			case <-ctx.Done():
+				return status.FromContextError(ctx.Err()).Err()
+			}
+		}
+		if rule.Hang {
+			log.Printf("[Fallas %s] Bloqueando %s hasta que el cliente cancele", in.name, method)
+			<-ctx.Done()
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		if rule.Drop {
+			log.Printf("[Fallas %s] Cerrando la conexión en %s", in.name, method)
+			drop()
+			return status.Error(codes.Unavailable, "conexión cerrada por falla inyectada")
+		}
+		if rule.ErrorCode != 0 {
+			return status.Errorf(codes.Code(rule.ErrorCode), "falla inyectada en %s", method)
+		}
+	}
+	return nil
+}
+
+// Reemplaza la carga de la respuesta por la falsa de las reglas override_load
+func (in *Injector) fakeLoad(resp interface{}, rules []*pb.FaultRule) {
+	load, ok := resp.(*pb.LoadResponse)
+	if !ok {
+		return
+	}
+	for _, rule := range rules {
+		if rule.OverrideLoad && in.lieAboutLoad(rule) {
+			load.Load = rule.FakeLoad
+			load.QueueDepth = 0
+			if load.Capacity > 0 {
+				load.Utilization = float64(rule.FakeLoad) / float64(load.Capacity)
+			}
+		}
+	}
+}
+
+// Interceptor unario que aplica las fallas configuradas a las llamadas recibidas
+func (in *Injector) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	method := shortMethod(info.FullMethod)
+	if method == "SetFaults" {
+		return handler(ctx, req)
+	}
+
+	rules := in.match(method, req, false, "")
+	if err := in.inject(ctx, method, rules, func() { in.listener.Drop(ctx) }); err != nil {
+		return nil, err
+	}
+	resp, err := handler(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+	in.fakeLoad(resp, rules)
+	return resp, nil
+}
+
+// Interceptor de cliente que aplica las reglas outbound a las llamadas que el
+// balanceador reenvía a los servidores; drop cierra la conexión saliente
+func (in *Injector) UnaryClientInterceptor(ctx context.Context, fullMethod string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	method := shortMethod(fullMethod)
+	rules := in.match(method, req, true, cc.Target())
+	if err := in.inject(ctx, method+" hacia "+cc.Target(), rules, func() { cc.Close() }); err != nil {
+		return err
+	}
+	if err := invoker(ctx, fullMethod, req, reply, cc, opts...); err != nil {
+		return err
+	}
+	in.fakeLoad(reply, rules)
+	return nil
+}
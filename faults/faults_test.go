@@ -0,0 +1,72 @@
+package faults
+
+import (
+	"context"
+	"testing"
+
+	pb "Distributed_load_balancer/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestInjectorDirection(t *testing.T) {
+	in := NewInjector("prueba", nil)
+	in.Set(&pb.FaultConfig{Rules: []*pb.FaultRule{
+		{Method: "ProcessRequest", ErrorCode: int32(codes.Internal)},
+		// La regla del servidor indicado va antes que la general: si el filtro
+		// por servidor no funcionara, backend-1 también recibiría DataLoss
+		{Method: "ProcessRequest", Outbound: true, Backend: "backend-2", ErrorCode: int32(codes.DataLoss)},
+		{Method: "ProcessRequest", Outbound: true, ErrorCode: int32(codes.Unavailable)},
+		{Method: "GetLoad", Outbound: true, OverrideLoad: true, FakeLoad: 7},
+	}})
+
+	t.Run("entrante", func(t *testing.T) {
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) { return &pb.Response{}, nil }
+		info := &grpc.UnaryServerInfo{FullMethod: "/proto.LoadBalancerService/ProcessRequest"}
+		if _, err := in.UnaryInterceptor(context.Background(), &pb.Request{}, info, handler); status.Code(err) != codes.Internal {
+			t.Errorf("código %v, se esperaba %v", status.Code(err), codes.Internal)
+		}
+	})
+
+	tests := []struct {
+		name     string
+		backend  string
+		method   string
+		want     codes.Code
+		wantLoad int32
+	}{
+		{"todos los servidores", "backend-1", "ProcessRequest", codes.Unavailable, 0},
+		{"servidor indicado", "backend-2", "ProcessRequest", codes.DataLoss, 0},
+		{"carga falsa", "backend-1", "GetLoad", codes.OK, 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cc, err := grpc.Dial(tt.backend, grpc.WithInsecure())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer cc.Close()
+			invoked := false
+			invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				invoked = true
+				if load, ok := reply.(*pb.LoadResponse); ok {
+					load.Load = 1
+				}
+				return nil
+			}
+			reply := &pb.LoadResponse{}
+			err = in.UnaryClientInterceptor(context.Background(), "/proto.LoadBalancerService/"+tt.method, &pb.Request{}, reply, cc, invoker)
+			if code := status.Code(err); code != tt.want {
+				t.Errorf("código %v, se esperaba %v", code, tt.want)
+			}
+			if invoked != (tt.want == codes.OK) {
+				t.Errorf("invoked = %v con código %v", invoked, tt.want)
+			}
+			if tt.want == codes.OK && reply.Load != tt.wantLoad {
+				t.Errorf("carga %d, se esperaba %d", reply.Load, tt.wantLoad)
+			}
+		})
+	}
+}
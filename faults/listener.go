@@ -0,0 +1,69 @@
+package faults
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"google.golang.org/grpc/peer"
+)
+
+// Listener que recuerda las conexiones abiertas para poder cerrarlas
+type Listener struct {
+	net.Listener
+	mu    sync.Mutex
+	conns map[*trackedConn]struct{}
+}
+
+// Envuelve un listener para registrar sus conexiones
+func NewListener(l net.Listener) *Listener {
+	return &Listener{Listener: l, conns: make(map[*trackedConn]struct{})}
+}
+
+func (l *Listener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	tracked := &trackedConn{Conn: conn, listener: l}
+	l.mu.Lock()
+	l.conns[tracked] = struct{}{}
+	l.mu.Unlock()
+	return tracked, nil
+}
+
+// Cierra la conexión del cliente de la llamada
+func (l *Listener) Drop(ctx context.Context) {
+	if l == nil {
+		return
+	}
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return
+	}
+	var matched []*trackedConn
+	l.mu.Lock()
+	for conn := range l.conns {
+		if conn.RemoteAddr().String() == p.Addr.String() {
+			matched = append(matched, conn)
+		}
+	}
+	l.mu.Unlock()
+	// Se cierran fuera del candado: Close lo vuelve a tomar para borrarlas
+	for _, conn := range matched {
+		conn.Close()
+	}
+}
+
+// Conexión que se elimina del registro al cerrarse
+type trackedConn struct {
+	net.Conn
+	listener *Listener
+}
+
+func (c *trackedConn) Close() error {
+	c.listener.mu.Lock()
+	delete(c.listener.conns, c)
+	c.listener.mu.Unlock()
+	return c.Conn.Close()
+}
@@ -0,0 +1,89 @@
+package faults
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/peer"
+)
+
+// Abre n conexiones a un Listener sobre TCP y devuelve los dos extremos
+func dial(t *testing.T, l *Listener, n int) (clients, servers []net.Conn) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		client, err := net.Dial("tcp", l.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { client.Close() })
+		server, err := l.Accept()
+		if err != nil {
+			t.Fatal(err)
+		}
+		clients = append(clients, client)
+		servers = append(servers, server)
+	}
+	return clients, servers
+}
+
+func tracked(l *Listener) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.conns)
+}
+
+// Espera a que el cliente vea cerrada su conexión
+func closedByServer(client net.Conn) bool {
+	client.SetReadDeadline(time.Now().Add(time.Second))
+	_, err := client.Read(make([]byte, 1))
+	return err == io.EOF
+}
+
+func TestListenerDrop(t *testing.T) {
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := NewListener(inner)
+	defer l.Close()
+
+	clients, servers := dial(t, l, 3)
+	if n := tracked(l); n != 3 {
+		t.Fatalf("%d conexiones registradas, se esperaban 3", n)
+	}
+
+	// Drop cierra solo la conexión del cliente de la llamada y la borra del registro
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: servers[0].RemoteAddr()})
+	l.Drop(ctx)
+	if !closedByServer(clients[0]) {
+		t.Error("la conexión del cliente de la llamada sigue abierta")
+	}
+	if n := tracked(l); n != 2 {
+		t.Errorf("%d conexiones registradas tras Drop, se esperaban 2", n)
+	}
+	if _, err := servers[1].Write([]byte("x")); err != nil {
+		t.Errorf("Drop cerró otra conexión: %v", err)
+	}
+
+	// Volver a descartar la misma llamada no hace nada
+	l.Drop(ctx)
+	if n := tracked(l); n != 2 {
+		t.Errorf("%d conexiones registradas tras repetir Drop, se esperaban 2", n)
+	}
+
+	// Un cierre normal también la borra
+	servers[1].Close()
+	if n := tracked(l); n != 1 {
+		t.Errorf("%d conexiones registradas tras cerrar una, se esperaba 1", n)
+	}
+
+	// Sin peer o sin listener no hace nada
+	l.Drop(context.Background())
+	(*Listener)(nil).Drop(ctx)
+	if n := tracked(l); n != 1 {
+		t.Errorf("%d conexiones registradas, se esperaba 1", n)
+	}
+}
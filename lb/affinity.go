@@ -0,0 +1,142 @@
+package lb
+
+import (
+	"container/list"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// Claves de metadata de la afinidad de sesión
+const (
+	affinityMetadataKey = "x-affinity-key" // Clave elegida por el cliente
+	sessionMetadataKey  = "x-session"      // Token emitido por el balanceador y devuelto por el cliente
+)
+
+// Configuración de la afinidad de sesión
+type AffinityConfig struct {
+	TTL           time.Duration // Tiempo sin uso tras el que se olvida una sesión
+	MaxEntries    int           // Sesiones recordadas como máximo (se descartan las menos usadas)
+	IssueSessions bool          // Emitir un token de sesión a los clientes que no envían clave
+}
+
+type affinityEntry struct {
+	key     string
+	backend string
+	used    time.Time
+}
+
+// Tabla de sesiones -> servidor, acotada y con vencimiento por inactividad
+type AffinityTable struct {
+	mu      sync.Mutex
+	cfg     AffinityConfig
+	entries map[string]*list.Element
+	lru     *list.List // Frente = usada más recientemente
+}
+
+func NewAffinityTable(cfg AffinityConfig) *AffinityTable {
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = 10000
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = 10 * time.Minute
+	}
+	return &AffinityTable{cfg: cfg, entries: make(map[string]*list.Element), lru: list.New()}
+}
+
+// Servidor asignado a la sesión, si la sesión sigue vigente
+func (t *AffinityTable) Get(key string) (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	el, ok := t.entries[key]
+	if !ok {
+		return "", false
+	}
+	entry := el.Value.(*affinityEntry)
+	if time.Since(entry.used) > t.cfg.TTL {
+		t.removeLocked(el)
+		return "", false
+	}
+	entry.used = time.Now()
+	t.lru.MoveToFront(el)
+	return entry.backend, true
+}
+
+// Asigna la sesión al servidor
+func (t *AffinityTable) Set(key, backend string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if el, ok := t.entries[key]; ok {
+		entry := el.Value.(*affinityEntry)
+		entry.backend, entry.used = backend, time.Now()
+		t.lru.MoveToFront(el)
+		return
+	}
+	t.entries[key] = t.lru.PushFront(&affinityEntry{key: key, backend: backend, used: time.Now()})
+
+	// Descartar las sesiones vencidas o, si no alcanza, las menos usadas
+	for t.lru.Len() > t.cfg.MaxEntries {
+		t.removeLocked(t.lru.Back())
+	}
+	for el := t.lru.Back(); el != nil && time.Since(el.Value.(*affinityEntry).used) > t.cfg.TTL; el = t.lru.Back() {
+		t.removeLocked(el)
+	}
+}
+
+// Olvida la sesión
+func (t *AffinityTable) Delete(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if el, ok := t.entries[key]; ok {
+		t.removeLocked(el)
+	}
+}
+
+func (t *AffinityTable) removeLocked(el *list.Element) {
+	delete(t.entries, el.Value.(*affinityEntry).key)
+	t.lru.Remove(el)
+}
+
+func (t *AffinityTable) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.lru.Len()
+}
+
+// Indica si la sesión existe y sigue vigente, sin renovarla
+func (t *AffinityTable) has(key string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	el, ok := t.entries[key]
+	return ok && time.Since(el.Value.(*affinityEntry).used) <= t.cfg.TTL
+}
+
+// Clave de afinidad de la solicitud: la del cliente o su token de sesión
+// ("" si no envió ninguna). Si corresponde emitir una sesión nueva, token
+// es el valor a devolver al cliente. Un token de sesión que el balanceador
+// no emitió o que ya venció se trata como si no viniera: el cliente no puede
+// elegir a qué sesión unirse ni llenar la tabla con tokens inventados
+func (t *AffinityTable) keyFromContext(ctx context.Context) (key, token string) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(affinityMetadataKey); len(values) > 0 && strings.TrimSpace(values[0]) != "" {
+			return affinityMetadataKey + ":" + strings.TrimSpace(values[0]), ""
+		}
+		if values := md.Get(sessionMetadataKey); len(values) > 0 && strings.TrimSpace(values[0]) != "" {
+			if key := sessionMetadataKey + ":" + strings.TrimSpace(values[0]); t.has(key) {
+				return key, ""
+			}
+		}
+	}
+	if !t.cfg.IssueSessions {
+		return "", ""
+	}
+	raw := make([]byte, 16)
+	rand.Read(raw)
+	token = hex.EncodeToString(raw)
+	return sessionMetadataKey + ":" + token, token
+}
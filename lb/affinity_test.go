@@ -0,0 +1,89 @@
+package lb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+)
+
+func TestAffinityTable(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     AffinityConfig
+		set     []string      // Sesiones asignadas en orden, cada una a "srv-"+clave
+		touch   string        // Sesión usada después de asignarlas todas
+		elapsed time.Duration // Antigüedad simulada de todas las sesiones
+		want    map[string]bool
+	}{
+		{"asignadas", AffinityConfig{}, []string{"a", "b"}, "", 0, map[string]bool{"a": true, "b": true}},
+		{"se descarta la menos usada", AffinityConfig{MaxEntries: 2}, []string{"a", "b", "c"}, "", 0, map[string]bool{"a": false, "b": true, "c": true}},
+		{"usar una sesión la conserva", AffinityConfig{MaxEntries: 2}, []string{"a", "b", "a", "c"}, "", 0, map[string]bool{"a": true, "b": false, "c": true}},
+		{"vencidas", AffinityConfig{TTL: time.Minute}, []string{"a"}, "", 2 * time.Minute, map[string]bool{"a": false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := NewAffinityTable(tt.cfg)
+			for _, key := range tt.set {
+				table.Set(key, "srv-"+key)
+			}
+			if tt.elapsed > 0 {
+				for _, el := range table.entries {
+					el.Value.(*affinityEntry).used = time.Now().Add(-tt.elapsed)
+				}
+			}
+			for key, want := range tt.want {
+				backend, ok := table.Get(key)
+				if ok != want || (ok && backend != "srv-"+key) {
+					t.Errorf("Get(%s) = %q, %v, se esperaba presente: %v", key, backend, ok, want)
+				}
+			}
+		})
+	}
+}
+
+func TestAffinityKeyFromContext(t *testing.T) {
+	table := NewAffinityTable(AffinityConfig{IssueSessions: true})
+	table.Set(sessionMetadataKey+":emitido", "backend-1")
+
+	tests := []struct {
+		name      string
+		md        metadata.MD
+		want      string // Clave esperada ("nueva" = sesión recién emitida)
+		wantToken bool
+	}{
+		{"clave del cliente", metadata.Pairs(affinityMetadataKey, "carrito-1"), affinityMetadataKey + ":carrito-1", false},
+		{"clave del cliente antes que la sesión", metadata.Pairs(affinityMetadataKey, "carrito-1", sessionMetadataKey, "emitido"), affinityMetadataKey + ":carrito-1", false},
+		{"sesión emitida", metadata.Pairs(sessionMetadataKey, " emitido "), sessionMetadataKey + ":emitido", false},
+		{"sesión inventada", metadata.Pairs(sessionMetadataKey, "inventado"), "nueva", true},
+		{"sin clave", metadata.MD{}, "nueva", true},
+		{"clave vacía", metadata.Pairs(affinityMetadataKey, " "), "nueva", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, token := table.keyFromContext(metadata.NewIncomingContext(context.Background(), tt.md))
+			if (token != "") != tt.wantToken {
+				t.Fatalf("token %q, se esperaba uno nuevo: %v", token, tt.wantToken)
+			}
+			if tt.want == "nueva" {
+				if token == "inventado" || key != sessionMetadataKey+":"+token {
+					t.Errorf("clave %q con token %q", key, token)
+				}
+				return
+			}
+			if key != tt.want {
+				t.Errorf("clave %q, se esperaba %q", key, tt.want)
+			}
+		})
+	}
+
+	// Sin emitir sesiones, un token desconocido no da clave
+	table = NewAffinityTable(AffinityConfig{})
+	if key, token := table.keyFromContext(metadata.NewIncomingContext(context.Background(), metadata.Pairs(sessionMetadataKey, "inventado"))); key != "" || token != "" {
+		t.Errorf("clave %q, token %q para una sesión inventada", key, token)
+	}
+	if table.Len() != 0 {
+		t.Errorf("la tabla guardó %d sesiones", table.Len())
+	}
+}
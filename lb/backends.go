@@ -0,0 +1,349 @@
+package lb
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"Distributed_load_balancer/balancer"
+	pb "Distributed_load_balancer/proto"
+)
+
+// Estado observado de un servidor desde el balanceador
+type backendState struct {
+	address   string
+	draining  bool // No recibe solicitudes nuevas
+	healthy   bool
+	lastErr   string
+	load      *pb.LoadResponse // Última carga consultada o informada
+	probedAt  time.Time        // Última actualización de la carga
+	reported  bool             // La última carga llegó en una respuesta
+	sent      int32            // Solicitudes asignadas desde la última carga
+	report    *pb.LoadReport   // Último reporte de la suscripción a la carga
+	watching  bool             // La suscripción a la carga está activa
+	requests  uint64
+	errors    uint64
+	latencyNs int64     // Suma de latencias de las solicitudes reenviadas
+	warmSince time.Time // Inicio del arranque lento (cero = peso completo)
+}
+
+// Estado de un servidor para el panel y las herramientas de administración
+type BackendStatus struct {
+	Address     string             `json:"address"`
+	Healthy     bool               `json:"healthy"`
+	Draining    bool               `json:"draining"`
+	Weight      float64            `json:"weight"`   // Peso de arranque lento en (0, 1]
+	Excluded    bool               `json:"excluded"` // No recibe solicitudes nuevas: caído, drenado o con el circuito abierto
+	LastError   string             `json:"last_error,omitempty"`
+	Load        int32              `json:"load"`
+	Capacity    int32              `json:"capacity"`
+	QueueDepth  int32              `json:"queue_depth"`
+	Utilization float64            `json:"utilization"`
+	ProbedAt    time.Time          `json:"probed_at"`
+	Reported    bool               `json:"reported"` // La carga llegó en una respuesta o un reporte y no en GetLoad
+	Watching    bool               `json:"watching"` // Suscripto a los reportes de carga del servidor
+	CPU         float64            `json:"cpu_utilization"`
+	MemoryBytes uint64             `json:"memory_bytes"`
+	MemoryPct   float64            `json:"memory_pct"`
+	Metrics     map[string]float64 `json:"metrics,omitempty"` // Métricas propias del último reporte
+	Requests    uint64             `json:"requests"`          // Solicitudes reenviadas (acumulado)
+	Errors      uint64             `json:"errors"`            // Solicitudes fallidas (acumulado)
+	LatencyMs   float64            `json:"latency_ms"`        // Suma de latencias en ms (acumulado)
+	Breaker     *BreakerStatus     `json:"breaker,omitempty"` // Circuito del servidor (nil = sin circuitos)
+}
+
+// Registro del estado de los servidores
+type backendRegistry struct {
+	mu        sync.Mutex
+	backends  map[string]*backendState
+	order     []string
+	slowStart *SlowStartConfig // nil = sin arranque lento
+}
+
+func newBackendRegistry(servers []string) *backendRegistry {
+	r := &backendRegistry{backends: make(map[string]*backendState)}
+	for _, server := range servers {
+		r.getLocked(server)
+	}
+	return r
+}
+
+// Agrega los servidores que todavía no se conocen
+func (r *backendRegistry) add(servers []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, server := range servers {
+		r.getLocked(server)
+	}
+}
+
+// Obtiene o crea el estado de un servidor
+func (r *backendRegistry) getLocked(address string) *backendState {
+	b, ok := r.backends[address]
+	if !ok {
+		b = &backendState{address: address, healthy: true}
+		r.backends[address] = b
+		r.order = append(r.order, address)
+		r.warmLocked(b, "agregado")
+	}
+	return b
+}
+
+// Activa el arranque lento a partir de ahora; los servidores ya conocidos
+// empiezan con peso completo
+func (r *backendRegistry) setSlowStart(cfg *SlowStartConfig) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.slowStart = cfg
+}
+
+// Empieza el arranque lento del servidor
+func (r *backendRegistry) warmLocked(b *backendState, reason string) {
+	if r.slowStart == nil || r.slowStart.Window <= 0 {
+		return
+	}
+	b.warmSince = time.Now()
+	log.Printf("Servidor %s %s: arranque lento durante %v", b.address, reason, r.slowStart.Window)
+}
+
+// Marca como recuperado un servidor (ej. al cerrarse su circuito)
+func (r *backendRegistry) recovered(address string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.warmLocked(r.getLocked(address), "recuperado")
+}
+
+// Indica si el servidor no debe recibir solicitudes nuevas
+func (b *backendState) excluded() bool {
+	return b.draining || !b.healthy
+}
+
+// Peso efectivo del servidor en (0, 1]: menor a 1 durante el arranque lento.
+// No dice si el servidor recibe solicitudes; eso lo indica excluded
+func (r *backendRegistry) weightLocked(b *backendState, now time.Time) float64 {
+	if b.warmSince.IsZero() {
+		return 1
+	}
+	w := r.slowStart.weight(now.Sub(b.warmSince))
+	if w >= 1 {
+		b.warmSince = time.Time{}
+	}
+	return w
+}
+
+// Completa el peso efectivo de los candidatos y descarta los que dejaron de
+// recibir solicitudes desde que se eligió el pool
+func (r *backendRegistry) weigh(candidates []balancer.Candidate) []balancer.Candidate {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	weighed := candidates[:0]
+	for _, c := range candidates {
+		b := r.getLocked(c.Address)
+		if b.excluded() {
+			continue
+		}
+		c.Weight = r.weightLocked(b, now)
+		weighed = append(weighed, c)
+	}
+	return weighed
+}
+
+// Servidores del pool que pueden recibir solicitudes nuevas
+func (r *backendRegistry) available(pool []string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var servers []string
+	for _, server := range pool {
+		if !r.getLocked(server).draining {
+			servers = append(servers, server)
+		}
+	}
+	return servers
+}
+
+// Última carga conocida del servidor si puede recibir solicitudes
+// (sano y sin drenar)
+func (r *backendRegistry) usable(address string) (balancer.Candidate, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.backends[address]
+	if !ok || b.draining || !b.healthy {
+		return balancer.Candidate{}, false
+	}
+	c := balancer.Candidate{Address: address}
+	if b.load != nil {
+		c.Load, c.Capacity, c.QueueDepth, c.Utilization = b.load.Load, b.load.Capacity, b.load.QueueDepth, b.load.Utilization
+	}
+	return c, true
+}
+
+// Solicitudes reenviadas a partir de las que se confía en la latencia media
+const minLatencySamples = 10
+
+// Latencia media de las solicitudes reenviadas al servidor, si hay suficientes
+func (r *backendRegistry) meanLatency(address string) (time.Duration, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.backends[address]
+	if !ok || b.requests < minLatencySamples {
+		return 0, false
+	}
+	return time.Duration(b.latencyNs / int64(b.requests)), true
+}
+
+// Registra el resultado de una consulta de carga
+func (r *backendRegistry) probed(c balancer.Candidate, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b := r.getLocked(c.Address)
+	b.probedAt = time.Now()
+	if err == nil && !b.healthy {
+		r.warmLocked(b, "recuperado")
+	}
+	b.healthy = err == nil
+	if err != nil {
+		b.lastErr = err.Error()
+		return
+	}
+	b.lastErr = ""
+	b.load = &pb.LoadResponse{Load: c.Load, Capacity: c.Capacity, QueueDepth: c.QueueDepth, Utilization: c.Utilization}
+	b.reported, b.sent = false, 0
+}
+
+// Registra la carga que el servidor adjuntó a una respuesta
+func (r *backendRegistry) loadReported(address string, load *pb.LoadResponse) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reportedLocked(r.getLocked(address), load)
+}
+
+func (r *backendRegistry) reportedLocked(b *backendState, load *pb.LoadResponse) {
+	if !b.healthy {
+		r.warmLocked(b, "recuperado")
+	}
+	b.healthy, b.lastErr = true, ""
+	b.load, b.probedAt = load, time.Now()
+	b.reported, b.sent = true, 0
+}
+
+// Registra un reporte de la suscripción a la carga del servidor
+func (r *backendRegistry) watched(address string, report *pb.LoadReport) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b := r.getLocked(address)
+	b.report, b.watching = report, true
+	if report.Load != nil {
+		r.reportedLocked(b, report.Load)
+	}
+}
+
+// Registra el fin de la suscripción a la carga del servidor; err != nil lo
+// marca como caído hasta que responda otra vez
+func (r *backendRegistry) unwatched(address string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b := r.getLocked(address)
+	b.watching = false
+	if err != nil {
+		b.healthy, b.lastErr = false, err.Error()
+	}
+}
+
+// Cuenta una solicitud asignada al servidor después de su última carga
+func (r *backendRegistry) forwarded(address string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.getLocked(address).sent++
+}
+
+// Carga informada por el servidor hace menos de maxAge, sumando las
+// solicitudes que se le enviaron después; false si hay que consultarla
+func (r *backendRegistry) fresh(address string, maxAge time.Duration) (balancer.Candidate, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.backends[address]
+	if !ok || !b.healthy || !b.reported || time.Since(b.probedAt) > maxAge {
+		return balancer.Candidate{}, false
+	}
+	c := balancer.Candidate{Address: address, Load: b.load.Load + b.sent, Capacity: b.load.Capacity, QueueDepth: b.load.QueueDepth}
+	c.Utilization = b.load.Utilization
+	if c.Capacity > 0 {
+		c.Utilization = float64(c.Load+c.QueueDepth) / float64(c.Capacity)
+	}
+	return c, true
+}
+
+// Registra el resultado de una solicitud reenviada
+func (r *backendRegistry) record(address string, rtt time.Duration, failed bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b := r.getLocked(address)
+	b.requests++
+	b.latencyNs += int64(rtt)
+	if failed {
+		b.errors++
+	}
+}
+
+// Marca un servidor para que deje de recibir (o vuelva a recibir) solicitudes nuevas
+func (r *backendRegistry) setDraining(address string, draining bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.backends[address]
+	if !ok {
+		return fmt.Errorf("servidor desconocido: %s", address)
+	}
+	if b.draining && !draining {
+		r.warmLocked(b, "habilitado")
+	}
+	b.draining = draining
+	return nil
+}
+
+// Servidores sin consultar desde hace más de maxAge
+func (r *backendRegistry) stale(maxAge time.Duration) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var servers []string
+	for _, address := range r.order {
+		if b := r.backends[address]; !b.draining && time.Since(b.probedAt) > maxAge {
+			servers = append(servers, address)
+		}
+	}
+	return servers
+}
+
+func (r *backendRegistry) status() []BackendStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []BackendStatus
+	now := time.Now()
+	for _, address := range r.order {
+		b := r.backends[address]
+		s := BackendStatus{
+			Address:   b.address,
+			Healthy:   b.healthy,
+			Draining:  b.draining,
+			Weight:    r.weightLocked(b, now),
+			Excluded:  b.excluded(),
+			LastError: b.lastErr,
+			ProbedAt:  b.probedAt,
+			Reported:  b.reported,
+			Requests:  b.requests,
+			Errors:    b.errors,
+			LatencyMs: float64(b.latencyNs) / float64(time.Millisecond),
+		}
+		if b.load != nil {
+			s.Load, s.Capacity, s.QueueDepth, s.Utilization = b.load.Load, b.load.Capacity, b.load.QueueDepth, b.load.Utilization
+		}
+		if b.report != nil {
+			s.CPU, s.MemoryBytes, s.MemoryPct = b.report.CpuUtilization, b.report.MemoryBytes, 100*b.report.MemoryUtilization
+			s.Metrics = b.report.NamedMetrics
+		}
+		s.Watching = b.watching
+		out = append(out, s)
+	}
+	return out
+}
@@ -0,0 +1,291 @@
+package lb
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Estados del circuito de un servidor
+const (
+	BreakerClosed   = "closed"    // Pasan todas las solicitudes
+	BreakerOpen     = "open"      // No pasa ninguna hasta que vence el tiempo abierto
+	BreakerHalfOpen = "half-open" // Pasan unas pocas solicitudes de prueba
+)
+
+// Divisiones de la ventana móvil
+const breakerBuckets = 10
+
+// Umbrales del circuito de un servidor
+type BreakerConfig struct {
+	ErrorRate           float64 `json:"error_rate"`           // Tasa de error en la ventana que abre el circuito (0 = no se mira)
+	MinRequests         int     `json:"min_requests"`         // Solicitudes en la ventana antes de mirar la tasa de error
+	ConsecutiveFailures int     `json:"consecutive_failures"` // Fallas seguidas que abren el circuito (0 = no se mira)
+	WindowMs            int64   `json:"window_ms"`            // Ventana móvil (0 = 10s)
+	OpenMs              int64   `json:"open_ms"`              // Tiempo abierto antes de probar de nuevo (0 = 5s)
+	HalfOpenProbes      int     `json:"half_open_probes"`     // Solicitudes de prueba en semiabierto (0 = 1)
+}
+
+// Archivo de circuitos: umbrales por defecto y por servidor (los campos en
+// cero de un servidor toman el valor por defecto)
+type BreakersFile struct {
+	Default  BreakerConfig            `json:"default"`
+	Backends map[string]BreakerConfig `json:"backends"`
+}
+
+// Lee la configuración de los circuitos desde un archivo JSON
+func ReadBreakersFromFile(filename string) (*BreakersFile, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error al leer el archivo de circuitos: %v", err)
+	}
+	var cfg BreakersFile
+	if err := json.Unmarshal(content, &cfg); err != nil {
+		return nil, fmt.Errorf("error al interpretar el archivo de circuitos: %v", err)
+	}
+	if cfg.Default.ErrorRate == 0 && cfg.Default.ConsecutiveFailures == 0 {
+		return nil, fmt.Errorf("el circuito por defecto necesita error_rate o consecutive_failures")
+	}
+	return &cfg, nil
+}
+
+type breakerBucket struct {
+	start    time.Time
+	requests int
+	failures int
+}
+
+// Circuito de un servidor
+type breaker struct {
+	address     string
+	cfg         BreakerConfig
+	window      time.Duration
+	openFor     time.Duration
+	state       string
+	buckets     [breakerBuckets]breakerBucket
+	consecutive int
+	openedAt    time.Time
+	probes      int // Pruebas en curso en semiabierto
+	successes   int // Pruebas exitosas en semiabierto
+	trips       uint64
+}
+
+func newBreaker(address string, cfg BreakerConfig) *breaker {
+	b := &breaker{address: address, cfg: cfg, state: BreakerClosed}
+	b.window = time.Duration(cfg.WindowMs) * time.Millisecond
+	if b.window <= 0 {
+		b.window = 10 * time.Second
+	}
+	b.openFor = time.Duration(cfg.OpenMs) * time.Millisecond
+	if b.openFor <= 0 {
+		b.openFor = 5 * time.Second
+	}
+	if b.cfg.HalfOpenProbes <= 0 {
+		b.cfg.HalfOpenProbes = 1
+	}
+	return b
+}
+
+// Pasa de abierto a semiabierto si venció el tiempo abierto
+func (b *breaker) refresh(now time.Time) {
+	if b.state == BreakerOpen && now.Sub(b.openedAt) >= b.openFor {
+		b.state, b.probes, b.successes = BreakerHalfOpen, 0, 0
+		log.Printf("[Circuito %s] semiabierto", b.address)
+	}
+}
+
+// Indica si el circuito dejaría pasar una solicitud
+func (b *breaker) ready(now time.Time) bool {
+	b.refresh(now)
+	switch b.state {
+	case BreakerOpen:
+		return false
+	case BreakerHalfOpen:
+		return b.probes < b.cfg.HalfOpenProbes
+	}
+	return true
+}
+
+// Totales de la ventana móvil
+func (b *breaker) counts(now time.Time) (requests, failures int) {
+	for _, bucket := range b.buckets {
+		if now.Sub(bucket.start) < b.window {
+			requests += bucket.requests
+			failures += bucket.failures
+		}
+	}
+	return requests, failures
+}
+
+func (b *breaker) add(now time.Time, failed bool) {
+	width := b.window / breakerBuckets
+	start := now.Truncate(width)
+	bucket := &b.buckets[(start.UnixNano()/int64(width))%breakerBuckets]
+	if !bucket.start.Equal(start) {
+		*bucket = breakerBucket{start: start}
+	}
+	bucket.requests++
+	if failed {
+		bucket.failures++
+	}
+}
+
+func (b *breaker) trip(now time.Time, reason string) {
+	b.state, b.openedAt = BreakerOpen, now
+	b.trips++
+	log.Printf("[Circuito %s] abierto (%s)", b.address, reason)
+}
+
+// Registra el resultado de una solicitud; devuelve true si el circuito se cerró
+func (b *breaker) record(now time.Time, failed bool) bool {
+	if b.state == BreakerHalfOpen {
+		if b.probes > 0 {
+			b.probes--
+		}
+		if failed {
+			b.trip(now, "falló una solicitud de prueba")
+			return false
+		}
+		b.successes++
+		if b.successes >= b.cfg.HalfOpenProbes {
+			b.state, b.consecutive = BreakerClosed, 0
+			b.buckets = [breakerBuckets]breakerBucket{}
+			log.Printf("[Circuito %s] cerrado", b.address)
+			return true
+		}
+		return false
+	}
+	if b.state == BreakerOpen {
+		return false // Solicitudes que salieron antes de abrirse
+	}
+
+	b.add(now, failed)
+	if !failed {
+		b.consecutive = 0
+		return false
+	}
+	b.consecutive++
+	if n := b.cfg.ConsecutiveFailures; n > 0 && b.consecutive >= n {
+		b.trip(now, fmt.Sprintf("%d fallas seguidas", b.consecutive))
+		return false
+	}
+	requests, failures := b.counts(now)
+	if rate := float64(failures) / float64(requests); b.cfg.ErrorRate > 0 && requests >= b.cfg.MinRequests && rate >= b.cfg.ErrorRate {
+		b.trip(now, fmt.Sprintf("tasa de error %.0f%% en %d solicitudes", 100*rate, requests))
+	}
+	return false
+}
+
+// Estado del circuito de un servidor
+type BreakerStatus struct {
+	State    string  `json:"state"`
+	Requests int     `json:"requests"` // En la ventana móvil
+	Failures int     `json:"failures"`
+	Trips    uint64  `json:"trips"` // Veces que se abrió (acumulado)
+	ErrorPct float64 `json:"error_pct"`
+}
+
+// Circuitos de todos los servidores; nil = desactivados
+type Breakers struct {
+	mu       sync.Mutex
+	config   *BreakersFile
+	breakers map[string]*breaker
+}
+
+func NewBreakers(cfg *BreakersFile) *Breakers {
+	return &Breakers{config: cfg, breakers: make(map[string]*breaker)}
+}
+
+func (bs *Breakers) getLocked(address string) *breaker {
+	b, ok := bs.breakers[address]
+	if !ok {
+		cfg, d := bs.config.Backends[address], bs.config.Default
+		if cfg.ErrorRate == 0 {
+			cfg.ErrorRate = d.ErrorRate
+		}
+		if cfg.MinRequests == 0 {
+			cfg.MinRequests = d.MinRequests
+		}
+		if cfg.ConsecutiveFailures == 0 {
+			cfg.ConsecutiveFailures = d.ConsecutiveFailures
+		}
+		if cfg.WindowMs == 0 {
+			cfg.WindowMs = d.WindowMs
+		}
+		if cfg.OpenMs == 0 {
+			cfg.OpenMs = d.OpenMs
+		}
+		if cfg.HalfOpenProbes == 0 {
+			cfg.HalfOpenProbes = d.HalfOpenProbes
+		}
+		b = newBreaker(address, cfg)
+		bs.breakers[address] = b
+	}
+	return b
+}
+
+// Servidores del pool cuyo circuito deja pasar solicitudes
+func (bs *Breakers) ready(pool []string) []string {
+	if bs == nil {
+		return pool
+	}
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	now := time.Now()
+	var servers []string
+	for _, server := range pool {
+		if bs.getLocked(server).ready(now) {
+			servers = append(servers, server)
+		}
+	}
+	return servers
+}
+
+// Reserva el paso de una solicitud; en semiabierto ocupa una prueba
+func (bs *Breakers) allow(address string) bool {
+	if bs == nil {
+		return true
+	}
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	b := bs.getLocked(address)
+	if !b.ready(time.Now()) {
+		return false
+	}
+	if b.state == BreakerHalfOpen {
+		b.probes++
+	}
+	return true
+}
+
+// Registra el resultado de una solicitud que pasó por allow; devuelve true si
+// el circuito se cerró
+func (bs *Breakers) record(address string, failed bool) bool {
+	if bs == nil {
+		return false
+	}
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	return bs.getLocked(address).record(time.Now(), failed)
+}
+
+// Estado del circuito de un servidor (nil si están desactivados)
+func (bs *Breakers) status(address string) *BreakerStatus {
+	if bs == nil {
+		return nil
+	}
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	now := time.Now()
+	b := bs.getLocked(address)
+	b.refresh(now)
+	s := &BreakerStatus{State: b.state, Trips: b.trips}
+	s.Requests, s.Failures = b.counts(now)
+	if s.Requests > 0 {
+		s.ErrorPct = 100 * float64(s.Failures) / float64(s.Requests)
+	}
+	return s
+}
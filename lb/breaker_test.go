@@ -0,0 +1,106 @@
+package lb
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreakerTransitions(t *testing.T) {
+	// Paso de un escenario: en el instante at se pide paso (allow) o se
+	// registra una solicitud (ok o fail) y se espera el estado indicado
+	type step struct {
+		at    time.Duration
+		event string
+		want  string
+	}
+	tests := []struct {
+		name  string
+		cfg   BreakerConfig
+		steps []step
+	}{
+		{"fallas seguidas abren", BreakerConfig{ConsecutiveFailures: 3}, []step{
+			{0, "fail", BreakerClosed},
+			{0, "fail", BreakerClosed},
+			{0, "ok", BreakerClosed}, // Un éxito reinicia la cuenta
+			{0, "fail", BreakerClosed},
+			{0, "fail", BreakerClosed},
+			{0, "fail", BreakerOpen},
+		}},
+		{"tasa de error abre con mínimo de solicitudes", BreakerConfig{ErrorRate: 0.5, MinRequests: 4}, []step{
+			{0, "fail", BreakerClosed},
+			{0, "ok", BreakerClosed},
+			{0, "fail", BreakerClosed},
+			{0, "fail", BreakerOpen},
+		}},
+		{"la ventana olvida las fallas viejas", BreakerConfig{ErrorRate: 0.5, MinRequests: 2, WindowMs: 1000}, []step{
+			{0, "ok", BreakerClosed},
+			{1500 * time.Millisecond, "ok", BreakerClosed},
+			{1500 * time.Millisecond, "fail", BreakerOpen},
+		}},
+		{"abierto rechaza hasta vencer", BreakerConfig{ConsecutiveFailures: 1, OpenMs: 1000}, []step{
+			{0, "fail", BreakerOpen},
+			{500 * time.Millisecond, "deny", BreakerOpen},
+			{500 * time.Millisecond, "ok", BreakerOpen}, // Salió antes de abrirse: no cuenta
+			{time.Second, "allow", BreakerHalfOpen},
+			{time.Second, "deny", BreakerHalfOpen}, // Una sola prueba a la vez
+			{time.Second, "ok", BreakerClosed},
+		}},
+		{"prueba fallida vuelve a abrir", BreakerConfig{ConsecutiveFailures: 1, OpenMs: 1000}, []step{
+			{0, "fail", BreakerOpen},
+			{time.Second, "allow", BreakerHalfOpen},
+			{time.Second, "fail", BreakerOpen},
+			{1500 * time.Millisecond, "deny", BreakerOpen},
+			{2 * time.Second, "allow", BreakerHalfOpen},
+		}},
+		{"varias pruebas para cerrar", BreakerConfig{ConsecutiveFailures: 1, OpenMs: 1000, HalfOpenProbes: 2}, []step{
+			{0, "fail", BreakerOpen},
+			{time.Second, "allow", BreakerHalfOpen},
+			{time.Second, "allow", BreakerHalfOpen},
+			{time.Second, "deny", BreakerHalfOpen},
+			{time.Second, "ok", BreakerHalfOpen},
+			{time.Second, "ok", BreakerClosed},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBreaker("backend-1", tt.cfg)
+			start := time.Now()
+			for i, s := range tt.steps {
+				now := start.Add(s.at)
+				switch s.event {
+				case "allow", "deny":
+					ready := b.ready(now)
+					if ready != (s.event == "allow") {
+						t.Fatalf("paso %d: ready = %v", i+1, ready)
+					}
+					if ready && b.state == BreakerHalfOpen {
+						b.probes++
+					}
+				case "ok", "fail":
+					b.record(now, s.event == "fail")
+				}
+				if b.state != s.want {
+					t.Fatalf("paso %d (%s): estado %s, se esperaba %s", i+1, s.event, b.state, s.want)
+				}
+			}
+		})
+	}
+}
+
+func TestBreakersRecoveredOnClose(t *testing.T) {
+	bs := NewBreakers(&BreakersFile{Default: BreakerConfig{ConsecutiveFailures: 1, OpenMs: 1}})
+	bs.record("a", true)
+	if bs.allow("a") {
+		t.Fatal("el circuito abierto dejó pasar una solicitud")
+	}
+	time.Sleep(2 * time.Millisecond)
+	if !bs.allow("a") {
+		t.Fatal("el circuito semiabierto no dejó pasar la prueba")
+	}
+	if !bs.record("a", false) {
+		t.Error("record no informó el cierre del circuito")
+	}
+	if got := bs.status("a"); got.State != BreakerClosed || got.Trips != 1 {
+		t.Errorf("estado %+v", got)
+	}
+}
@@ -0,0 +1,272 @@
+package lb
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Destino de un reparto ponderado entre pools
+type SplitTarget struct {
+	Pool   string  `json:"pool"`
+	Weight float64 `json:"weight"` // Proporción relativa del tráfico (ej. 95 y 5)
+}
+
+// Reversión automática del pool canario cuando empeora respecto al resto del reparto
+type RollbackConfig struct {
+	Canary            string  `json:"canary"`               // Pool canario; los demás pools del reparto son la referencia
+	MaxErrorRateDelta float64 `json:"max_error_rate_delta"` // Tasa de error tolerada por encima de la referencia (0 = no se mira)
+	MaxLatencyRatio   float64 `json:"max_latency_ratio"`    // Latencia media tolerada respecto a la referencia (0 = no se mira)
+	MinRequests       int     `json:"min_requests"`         // Solicitudes del canario en la ventana antes de evaluar
+	WindowMs          int64   `json:"window_ms"`            // Ventana de observación (0 = 60s)
+}
+
+// Estado de un pool dentro de un reparto
+type SplitStatus struct {
+	Route      string  `json:"route"`
+	Pool       string  `json:"pool"`
+	Weight     float64 `json:"weight"`
+	Percent    float64 `json:"percent"`
+	Canary     bool    `json:"canary,omitempty"`
+	Requests   int     `json:"requests"` // En la ventana actual
+	Errors     int     `json:"errors"`
+	LatencyMs  float64 `json:"latency_ms"` // Media en la ventana actual
+	RolledBack string  `json:"rolled_back,omitempty"`
+}
+
+type splitTarget struct {
+	pool    string
+	servers []string
+	weight  float64
+}
+
+// Solicitudes de un pool en la ventana de observación
+type splitWindow struct {
+	requests int
+	errors   int
+	latency  time.Duration
+}
+
+func (w *splitWindow) errorRate() float64 {
+	if w.requests == 0 {
+		return 0
+	}
+	return float64(w.errors) / float64(w.requests)
+}
+
+func (w *splitWindow) meanLatency() time.Duration {
+	if w.requests == 0 {
+		return 0
+	}
+	return w.latency / time.Duration(w.requests)
+}
+
+// Reparto ponderado de una ruta con sus pesos ajustables y su reversión
+type splitState struct {
+	mu          sync.Mutex
+	route       string
+	targets     []splitTarget
+	configured  []splitTarget // Reparto del archivo, para reconocerlo al recargar
+	rollback    *RollbackConfig
+	window      time.Duration
+	windowStart time.Time
+	stats       map[string]*splitWindow
+	rolledBack  string // Motivo de la reversión ("" = no se revirtió)
+}
+
+func newSplitState(route string, targets []splitTarget, rollback *RollbackConfig) (*splitState, error) {
+	s := &splitState{
+		route:       route,
+		targets:     targets,
+		configured:  append([]splitTarget(nil), targets...),
+		rollback:    rollback,
+		windowStart: time.Now(),
+		stats:       make(map[string]*splitWindow),
+	}
+	total := 0.0
+	for _, t := range targets {
+		if t.weight < 0 {
+			return nil, fmt.Errorf("ruta %s: peso negativo para el pool %s", route, t.pool)
+		}
+		total += t.weight
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("ruta %s: el reparto no tiene pesos positivos", route)
+	}
+	if rollback != nil {
+		if s.target(rollback.Canary) == nil {
+			return nil, fmt.Errorf("ruta %s: el pool canario %s no está en el reparto", route, rollback.Canary)
+		}
+		if rollback.MaxErrorRateDelta <= 0 && rollback.MaxLatencyRatio <= 0 {
+			return nil, fmt.Errorf("ruta %s: la reversión necesita max_error_rate_delta o max_latency_ratio", route)
+		}
+		s.window = time.Duration(rollback.WindowMs) * time.Millisecond
+		if s.window <= 0 {
+			s.window = time.Minute
+		}
+	}
+	return s, nil
+}
+
+// Conserva los pesos ajustados, la ventana y la reversión del reparto anterior
+// de la misma ruta si el archivo no cambió sus pools, servidores, pesos ni
+// reversión; así recargar no reactiva un canario revertido
+func (s *splitState) inherit(old *splitState) {
+	if old == nil || old.route != s.route || !reflect.DeepEqual(old.configured, s.configured) ||
+		!reflect.DeepEqual(old.rollback, s.rollback) {
+		return
+	}
+	old.mu.Lock()
+	defer old.mu.Unlock()
+	s.targets = append([]splitTarget(nil), old.targets...)
+	s.windowStart, s.rolledBack = old.windowStart, old.rolledBack
+	for pool, w := range old.stats {
+		copied := *w
+		s.stats[pool] = &copied
+	}
+}
+
+func (s *splitState) target(pool string) *splitTarget {
+	for i := range s.targets {
+		if s.targets[i].pool == pool {
+			return &s.targets[i]
+		}
+	}
+	return nil
+}
+
+// Elige un pool al azar según los pesos
+func (s *splitState) pick() (string, []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	total := 0.0
+	for _, t := range s.targets {
+		total += t.weight
+	}
+	r := rand.Float64() * total
+	for _, t := range s.targets {
+		if t.weight > 0 && r < t.weight {
+			return t.pool, t.servers
+		}
+		r -= t.weight
+	}
+	// Redondeo: el último pool con peso
+	for i := len(s.targets) - 1; i >= 0; i-- {
+		if s.targets[i].weight > 0 {
+			return s.targets[i].pool, s.targets[i].servers
+		}
+	}
+	return s.targets[0].pool, s.targets[0].servers
+}
+
+// Cambia el peso de un pool; volver a dar peso al canario anula la reversión
+func (s *splitState) setWeight(pool string, weight float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if weight < 0 {
+		return fmt.Errorf("el peso no puede ser negativo")
+	}
+	t := s.target(pool)
+	if t == nil {
+		return fmt.Errorf("el pool %s no está en el reparto de la ruta %s", pool, s.route)
+	}
+	total := weight
+	for _, other := range s.targets {
+		if other.pool != pool {
+			total += other.weight
+		}
+	}
+	if total == 0 {
+		return fmt.Errorf("el reparto de la ruta %s quedaría sin pesos positivos", s.route)
+	}
+	t.weight = weight
+	if s.rollback != nil && pool == s.rollback.Canary && weight > 0 {
+		s.rolledBack = ""
+		s.windowStart, s.stats = time.Now(), make(map[string]*splitWindow)
+	}
+	return nil
+}
+
+// Registra el resultado de una solicitud enviada al pool y revierte el canario
+// si empeoró respecto a la referencia
+func (s *splitState) record(pool string, rtt time.Duration, failed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.rollback == nil {
+		return
+	}
+	if time.Since(s.windowStart) > s.window {
+		s.windowStart, s.stats = time.Now(), make(map[string]*splitWindow)
+	}
+	w, ok := s.stats[pool]
+	if !ok {
+		w = &splitWindow{}
+		s.stats[pool] = w
+	}
+	w.requests++
+	w.latency += rtt
+	if failed {
+		w.errors++
+	}
+
+	canary := s.target(s.rollback.Canary)
+	if s.rolledBack != "" || canary.weight == 0 {
+		return
+	}
+	c := s.stats[s.rollback.Canary]
+	if c == nil || c.requests < s.rollback.MinRequests {
+		return
+	}
+	var baseline splitWindow
+	for p, st := range s.stats {
+		if p != s.rollback.Canary {
+			baseline.requests += st.requests
+			baseline.errors += st.errors
+			baseline.latency += st.latency
+		}
+	}
+	if baseline.requests == 0 {
+		return
+	}
+
+	reason := ""
+	if d := s.rollback.MaxErrorRateDelta; d > 0 && c.errorRate()-baseline.errorRate() > d {
+		reason = fmt.Sprintf("tasa de error %.1f%% contra %.1f%%", 100*c.errorRate(), 100*baseline.errorRate())
+	} else if r := s.rollback.MaxLatencyRatio; r > 0 && float64(c.meanLatency()) > r*float64(baseline.meanLatency()) {
+		reason = fmt.Sprintf("latencia media %v contra %v", c.meanLatency().Round(time.Millisecond), baseline.meanLatency().Round(time.Millisecond))
+	}
+	if reason == "" {
+		return
+	}
+	canary.weight = 0
+	s.rolledBack = reason
+	log.Printf("[Canario] Ruta %s: pool %s revertido (%s)", s.route, canary.pool, reason)
+}
+
+func (s *splitState) status() []SplitStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	total := 0.0
+	for _, t := range s.targets {
+		total += t.weight
+	}
+	var out []SplitStatus
+	for _, t := range s.targets {
+		st := SplitStatus{Route: s.route, Pool: t.pool, Weight: t.weight}
+		if total > 0 {
+			st.Percent = 100 * t.weight / total
+		}
+		if w, ok := s.stats[t.pool]; ok {
+			st.Requests, st.Errors = w.requests, w.errors
+			st.LatencyMs = float64(w.meanLatency()) / float64(time.Millisecond)
+		}
+		if s.rollback != nil && t.pool == s.rollback.Canary {
+			st.Canary, st.RolledBack = true, s.rolledBack
+		}
+		out = append(out, st)
+	}
+	return out
+}
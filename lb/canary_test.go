@@ -0,0 +1,113 @@
+package lb
+
+import (
+	"testing"
+	"time"
+)
+
+// Solicitudes registradas en un pool del reparto
+type splitSample struct {
+	pool   string
+	n      int
+	rtt    time.Duration
+	failed bool
+}
+
+func TestCanaryRollback(t *testing.T) {
+	tests := []struct {
+		name     string
+		rollback RollbackConfig
+		samples  []splitSample
+		want     bool // Se espera la reversión
+	}{
+		{"errores del canario", RollbackConfig{MaxErrorRateDelta: 0.1, MinRequests: 10},
+			[]splitSample{{"stable", 50, 10 * time.Millisecond, false}, {"canary", 10, 10 * time.Millisecond, true}}, true},
+		{"errores dentro de la tolerancia", RollbackConfig{MaxErrorRateDelta: 0.5, MinRequests: 10},
+			[]splitSample{{"stable", 50, 10 * time.Millisecond, false}, {"canary", 8, 10 * time.Millisecond, false}, {"canary", 2, 10 * time.Millisecond, true}}, false},
+		{"canario lento", RollbackConfig{MaxLatencyRatio: 2, MinRequests: 10},
+			[]splitSample{{"stable", 50, 10 * time.Millisecond, false}, {"canary", 10, 50 * time.Millisecond, false}}, true},
+		{"pocas solicitudes del canario", RollbackConfig{MaxErrorRateDelta: 0.1, MinRequests: 10},
+			[]splitSample{{"stable", 50, 10 * time.Millisecond, false}, {"canary", 9, 10 * time.Millisecond, true}}, false},
+		{"sin referencia", RollbackConfig{MaxErrorRateDelta: 0.1, MinRequests: 10},
+			[]splitSample{{"canary", 10, 10 * time.Millisecond, true}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rollback := tt.rollback
+			rollback.Canary = "canary"
+			s, err := newSplitState("r", []splitTarget{{pool: "stable", weight: 95}, {pool: "canary", weight: 5}}, &rollback)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, sample := range tt.samples {
+				for i := 0; i < sample.n; i++ {
+					s.record(sample.pool, sample.rtt, sample.failed)
+				}
+			}
+			rolledBack := s.rolledBack != ""
+			if rolledBack != tt.want || rolledBack != (s.target("canary").weight == 0) {
+				t.Errorf("revertido %v (%q, peso %v), se esperaba %v", rolledBack, s.rolledBack, s.target("canary").weight, tt.want)
+			}
+			if rolledBack {
+				// Volver a dar peso al canario anula la reversión
+				if err := s.setWeight("canary", 5); err != nil || s.rolledBack != "" {
+					t.Errorf("setWeight no anuló la reversión: %v %q", err, s.rolledBack)
+				}
+			}
+		})
+	}
+}
+
+func TestCanaryReload(t *testing.T) {
+	base := func(stableWeight float64) *RoutesFile {
+		return &RoutesFile{
+			Pools: map[string][]string{"stable": {"s1"}, "canary": {"c1"}, "otro": {"o1"}},
+			Routes: []Route{{
+				Name:     "api",
+				Match:    RouteMatch{Tenant: "a"},
+				Split:    []SplitTarget{{Pool: "stable", Weight: stableWeight}, {Pool: "canary", Weight: 5}},
+				Rollback: &RollbackConfig{Canary: "canary", MaxErrorRateDelta: 0.1, MinRequests: 1},
+			}},
+		}
+	}
+	tests := []struct {
+		name       string
+		reload     func(cfg *RoutesFile)
+		rolledBack bool // Se espera que la reversión siga vigente tras recargar
+	}{
+		{"archivo sin cambios", func(*RoutesFile) {}, true},
+		{"cambia otra ruta", func(cfg *RoutesFile) {
+			cfg.Routes = append(cfg.Routes, Route{Name: "otra", Match: RouteMatch{Tenant: "b"}, Pool: "otro"})
+		}, true},
+		{"cambian los pesos del reparto", func(cfg *RoutesFile) { cfg.Routes[0].Split[0].Weight = 90 }, false},
+		{"cambian los servidores del canario", func(cfg *RoutesFile) { cfg.Pools["canary"] = []string{"c2"} }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, filename := newTestRouter(t, base(95))
+			router.Match(RouteRequest{Tenant: "a"})
+			split := router.split("api")
+			split.record("stable", time.Millisecond, false)
+			split.record("canary", time.Millisecond, true)
+			if split.rolledBack == "" {
+				t.Fatal("el canario no se revirtió")
+			}
+
+			cfg := base(95)
+			tt.reload(cfg)
+			writeRoutes(t, filename, cfg)
+			if err := router.Reload(); err != nil {
+				t.Fatal(err)
+			}
+			var canary SplitStatus
+			for _, st := range router.Splits() {
+				if st.Pool == "canary" {
+					canary = st
+				}
+			}
+			if got := canary.RolledBack != ""; got != tt.rolledBack || got != (canary.Weight == 0) {
+				t.Errorf("revertido %v con peso %v, se esperaba revertido %v", got, canary.Weight, tt.rolledBack)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package lb
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Límites del limitador adaptativo
+const (
+	initialConcurrencyLimit = 20
+	minConcurrencyLimit     = 1
+	maxConcurrencyLimit     = 1000
+)
+
+// Algoritmo que recalcula el límite de concurrencia a partir de cada muestra
+type LimitAlgorithm interface {
+	Update(limit float64, rtt time.Duration, inFlight int, dropped bool) float64
+}
+
+// AIMD: suma uno mientras el límite se usa y multiplica por backoff ante errores o latencia alta
+type AIMDLimit struct {
+	Backoff float64       // Factor de reducción (ej. 0.9)
+	Timeout time.Duration // Latencia a partir de la cual la muestra cuenta como descarte
+}
+
+func (a *AIMDLimit) Update(limit float64, rtt time.Duration, inFlight int, dropped bool) float64 {
+	if dropped || (a.Timeout > 0 && rtt > a.Timeout) {
+		return limit * a.Backoff
+	}
+	if float64(inFlight)*2 >= limit {
+		return limit + 1
+	}
+	return limit
+}
+
+// Gradiente: compara la latencia reciente con la media de largo plazo (estilo Gradient2 de Netflix)
+type GradientLimit struct {
+	Smoothing float64 // Peso del nuevo límite en la media (0-1)
+	Tolerance float64 // Cuánto puede crecer la latencia antes de reducir el límite
+	Window    int     // Muestras de la media exponencial de largo plazo
+
+	longRTT float64 // Latencia de largo plazo en segundos
+	samples int
+}
+
+func (g *GradientLimit) Update(limit float64, rtt time.Duration, inFlight int, dropped bool) float64 {
+	short := rtt.Seconds()
+	if short <= 0 {
+		return limit
+	}
+
+	// Media exponencial de largo plazo (media simple durante el arranque)
+	g.samples++
+	if g.samples <= g.Window {
+		g.longRTT += (short - g.longRTT) / float64(g.samples)
+	} else {
+		factor := 2.0 / float64(g.Window+1)
+		g.longRTT = g.longRTT*(1-factor) + short*factor
+	}
+
+	// Si la latencia de largo plazo se fue muy por encima, dejar que se recupere
+	if g.longRTT/short > 2 {
+		g.longRTT *= 0.95
+	}
+
+	// Con poca carga no hay información para subir el límite
+	if float64(inFlight) < limit/2 && !dropped {
+		return limit
+	}
+
+	gradient := math.Max(0.5, math.Min(1.0, g.Tolerance*g.longRTT/short))
+	if dropped {
+		gradient = 0.5
+	}
+	queueSize := math.Sqrt(limit)
+	newLimit := limit*gradient + queueSize
+	return limit*(1-g.Smoothing) + newLimit*g.Smoothing
+}
+
+// Crea una instancia del algoritmo indicado
+func newLimitAlgorithm(name string) (LimitAlgorithm, error) {
+	switch name {
+	case "aimd":
+		return &AIMDLimit{Backoff: 0.9, Timeout: 5 * time.Second}, nil
+	case "gradient":
+		return &GradientLimit{Smoothing: 0.2, Tolerance: 1.5, Window: 600}, nil
+	}
+	return nil, fmt.Errorf("algoritmo de concurrencia desconocido: %s", name)
+}
+
+// Limitador de concurrencia adaptativo
+type ConcurrencyLimiter struct {
+	mu       sync.Mutex
+	name     string
+	algo     LimitAlgorithm
+	limit    float64
+	inFlight int
+	lastRTT  time.Duration
+
+	// Estadísticas
+	accepted int64
+	shed     int64
+	dropped  int64
+}
+
+// Intenta ocupar un cupo; devuelve false si se alcanzó el límite
+func (cl *ConcurrencyLimiter) TryAcquire() bool {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	if cl.inFlight >= int(cl.limit) {
+		cl.shed++
+		return false
+	}
+	cl.inFlight++
+	cl.accepted++
+	return true
+}
+
+// Libera el cupo y ajusta el límite con la latencia observada
+func (cl *ConcurrencyLimiter) Release(rtt time.Duration, dropped bool) {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	if dropped {
+		cl.dropped++
+	}
+	cl.lastRTT = rtt
+	limit := cl.algo.Update(cl.limit, rtt, cl.inFlight, dropped)
+	cl.limit = math.Max(minConcurrencyLimit, math.Min(maxConcurrencyLimit, limit))
+	cl.inFlight--
+}
+
+// Libera el cupo sin ajustar el límite, para solicitudes que no llegaron al
+// servidor (rechazadas en cola, sin servidor disponible, circuito abierto)
+func (cl *ConcurrencyLimiter) Cancel() {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	cl.inFlight--
+}
+
+// Límite actual
+func (cl *ConcurrencyLimiter) Limit() int {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	return int(cl.limit)
+}
+
+// Estado de un limitador en un instante
+type LimiterStats struct {
+	Name     string        `json:"name"`
+	Limit    int           `json:"limit"`
+	InFlight int           `json:"in_flight"`
+	LastRTT  time.Duration `json:"last_rtt_ns"`
+	Accepted int64         `json:"accepted"`
+	Shed     int64         `json:"shed"`
+	Dropped  int64         `json:"dropped"`
+}
+
+func (cl *ConcurrencyLimiter) Stats() LimiterStats {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	return LimiterStats{
+		Name:     cl.name,
+		Limit:    int(cl.limit),
+		InFlight: cl.inFlight,
+		LastRTT:  cl.lastRTT,
+		Accepted: cl.accepted,
+		Shed:     cl.shed,
+		Dropped:  cl.dropped,
+	}
+}
+
+// Conjunto de limitadores adaptativos, uno global o uno por servidor
+type AdaptiveLimits struct {
+	mu        sync.Mutex
+	algorithm string
+	perServer bool
+	limiters  map[string]*ConcurrencyLimiter
+}
+
+// Crea los limitadores para el algoritmo ("aimd" o "gradient") y alcance ("global" o "backend")
+func NewAdaptiveLimits(algorithm, scope string) (*AdaptiveLimits, error) {
+	if _, err := newLimitAlgorithm(algorithm); err != nil {
+		return nil, err
+	}
+	if scope != "global" && scope != "backend" {
+		return nil, fmt.Errorf("alcance de concurrencia desconocido: %s", scope)
+	}
+	return &AdaptiveLimits{
+		algorithm: algorithm,
+		perServer: scope == "backend",
+		limiters:  make(map[string]*ConcurrencyLimiter),
+	}, nil
+}
+
+// Obtiene o crea el limitador con el nombre dado
+func (al *AdaptiveLimits) limiter(name string) *ConcurrencyLimiter {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	cl, ok := al.limiters[name]
+	if !ok {
+		algo, _ := newLimitAlgorithm(al.algorithm)
+		cl = &ConcurrencyLimiter{name: name, algo: algo, limit: initialConcurrencyLimit}
+		al.limiters[name] = cl
+	}
+	return cl
+}
+
+// Limitador global (nil si está desactivado o es por servidor)
+func (al *AdaptiveLimits) Global() *ConcurrencyLimiter {
+	if al == nil || al.perServer {
+		return nil
+	}
+	return al.limiter("global")
+}
+
+// Limitador del servidor dado (nil si está desactivado o es global)
+func (al *AdaptiveLimits) Backend(server string) *ConcurrencyLimiter {
+	if al == nil || !al.perServer {
+		return nil
+	}
+	return al.limiter(server)
+}
+
+// Estado de todos los limitadores ordenado por nombre
+func (al *AdaptiveLimits) Stats() []LimiterStats {
+	if al == nil {
+		return nil
+	}
+	al.mu.Lock()
+	limiters := make([]*ConcurrencyLimiter, 0, len(al.limiters))
+	for _, cl := range al.limiters {
+		limiters = append(limiters, cl)
+	}
+	al.mu.Unlock()
+
+	stats := make([]LimiterStats, 0, len(limiters))
+	for _, cl := range limiters {
+		stats = append(stats, cl.Stats())
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+	return stats
+}
@@ -0,0 +1,126 @@
+package lb
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	pb "Distributed_load_balancer/proto"
+)
+
+func TestAIMDLimit(t *testing.T) {
+	aimd := &AIMDLimit{Backoff: 0.9, Timeout: time.Second}
+	tests := []struct {
+		name     string
+		limit    float64
+		rtt      time.Duration
+		inFlight int
+		dropped  bool
+		want     float64
+	}{
+		{"en uso sube uno", 20, 10 * time.Millisecond, 10, false, 21},
+		{"poco uso se mantiene", 20, 10 * time.Millisecond, 5, false, 20},
+		{"descarte reduce", 20, 10 * time.Millisecond, 10, true, 18},
+		{"latencia alta reduce", 20, 2 * time.Second, 10, false, 18},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := aimd.Update(tt.limit, tt.rtt, tt.inFlight, tt.dropped); got != tt.want {
+				t.Errorf("Update = %.2f, se esperaba %.2f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGradientLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		warmup  time.Duration // Latencia de las muestras iniciales
+		rtt     time.Duration // Latencia de la muestra evaluada
+		dropped bool
+		grows   bool // Se espera que el límite suba (si no, que baje)
+	}{
+		{"latencia estable sube", 10 * time.Millisecond, 10 * time.Millisecond, false, true},
+		{"latencia que se duplica baja", 10 * time.Millisecond, 40 * time.Millisecond, false, false},
+		{"descarte baja", 10 * time.Millisecond, 10 * time.Millisecond, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &GradientLimit{Smoothing: 0.2, Tolerance: 1.5, Window: 10}
+			limit := 100.0
+			for i := 0; i < 10; i++ {
+				g.Update(limit, tt.warmup, int(limit), false)
+			}
+			got := g.Update(limit, tt.rtt, int(limit), tt.dropped)
+			if tt.grows && got <= limit || !tt.grows && got >= limit {
+				t.Errorf("Update = %.2f desde %.2f, se esperaba que %s", got, limit, map[bool]string{true: "suba", false: "baje"}[tt.grows])
+			}
+		})
+	}
+}
+
+func TestConcurrencyLimiterShedsAndCancels(t *testing.T) {
+	cl := &ConcurrencyLimiter{name: "global", algo: &AIMDLimit{Backoff: 0.5}, limit: 2}
+	if !cl.TryAcquire() || !cl.TryAcquire() {
+		t.Fatal("los dos primeros cupos deben concederse")
+	}
+	if cl.TryAcquire() {
+		t.Fatal("el tercer cupo debe rechazarse")
+	}
+	cl.Cancel()
+	if got := cl.Limit(); got != 2 {
+		t.Errorf("Cancel cambió el límite a %d", got)
+	}
+	cl.Release(time.Millisecond, true)
+	s := cl.Stats()
+	if s.Limit != 1 || s.InFlight != 0 || s.Shed != 1 || s.Dropped != 1 || s.Accepted != 2 {
+		t.Errorf("estado inesperado: %+v", s)
+	}
+}
+
+// Las solicitudes que fallan sin llegar a un servidor no deben subir el límite
+func TestAdaptiveLimitIgnoresLocalFailures(t *testing.T) {
+	// Servidor que acepta conexiones y nunca responde: las consultas de carga
+	// vencen y las solicitudes se acumulan en curso antes de fallar
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	limits, err := NewAdaptiveLimits("aimd", "global")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lb := New(Config{
+		Servers:   []string{listener.Addr().String()},
+		Limits:    limits,
+		Deadlines: DeadlineConfig{ProbeTimeout: 50 * time.Millisecond},
+	})
+	var wg sync.WaitGroup
+	for i := 0; i < initialConcurrencyLimit; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := lb.ProcessRequest(context.Background(), &pb.Request{WorkId: int32(i)}); err == nil {
+				t.Error("se esperaba un error sin servidores disponibles")
+			}
+		}(i)
+	}
+	wg.Wait()
+	s := limits.Global().Stats()
+	if s.Limit != initialConcurrencyLimit || s.InFlight != 0 {
+		t.Errorf("límite %d con %d en curso, se esperaba %d sin cambios", s.Limit, s.InFlight, initialConcurrencyLimit)
+	}
+}
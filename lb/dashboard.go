@@ -0,0 +1,266 @@
+package lb
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"Distributed_load_balancer/auth"
+)
+
+//go:embed dashboard
+var dashboardFiles embed.FS
+
+// Estado completo del balanceador
+type Status struct {
+	Time     time.Time       `json:"time"`
+	Strategy string          `json:"strategy"`
+	Backends []BackendStatus `json:"backends"`
+	Tenants  []TenantStats   `json:"tenants"`
+	Limits   []LimiterStats  `json:"limits"`
+	Mirror   MirrorStats     `json:"mirror"`
+}
+
+func (lb *LoadBalancer) Status() Status {
+	return Status{
+		Time:     time.Now(),
+		Strategy: lb.strategy.Name(),
+		Backends: lb.Backends(),
+		Tenants:  lb.scheduler.Stats(),
+		Limits:   lb.limits.Stats(),
+		Mirror:   lb.MirrorStats(),
+	}
+}
+
+// Cabecera que deben enviar las acciones del panel cuando no hay autenticación:
+// un formulario de otro sitio no puede agregarla sin una consulta CORS previa,
+// que el panel no acepta
+const csrfHeader = "X-Requested-By"
+
+// Tiempo durante el que una consulta a /status cuenta como alguien mirando
+// el panel (lbctl top consulta cada segundo)
+const statusPollWindow = 5 * time.Second
+
+// Último estado calculado, compartido por todos los clientes del panel
+type statusCache struct {
+	mu     sync.Mutex
+	status Status
+	polled time.Time // Última consulta a /status
+}
+
+func (c *statusCache) get() Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.status
+}
+
+// Estado para una consulta a /status; registra que alguien está mirando
+func (c *statusCache) poll() Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.polled = time.Now()
+	return c.status
+}
+
+// Indica si hubo consultas a /status recientes
+func (c *statusCache) polledRecently() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return time.Since(c.polled) < statusPollWindow
+}
+
+func (c *statusCache) set(status Status) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.status = status
+}
+
+// Recalcula el estado una vez por segundo, sin importar cuántos clientes
+// tenga el panel. Solo consulta la carga vencida mientras alguien mira (un
+// cliente de eventos o consultas a /status recientes): sin nadie mirando el
+// estado se arma con lo que ya informan las solicitudes y suscripciones
+func (lb *LoadBalancer) refreshStatus(cache *statusCache) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		if lb.decisions.active() || cache.polledRecently() {
+			lb.RefreshStale(time.Second)
+		}
+		cache.set(lb.Status())
+	}
+}
+
+// Manejador HTTP del panel: la página embebida, el estado en JSON, los eventos
+// en vivo (Server-Sent Events), las acciones de drenar y habilitar servidores
+// y la consulta y recarga de las reglas de enrutamiento y sus repartos
+func (lb *LoadBalancer) DashboardHandler() http.Handler {
+	static, err := fs.Sub(dashboardFiles, "dashboard")
+	if err != nil {
+		panic(err)
+	}
+	cache := &statusCache{status: lb.Status()}
+	go lb.refreshStatus(cache)
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /", http.FileServer(http.FS(static)))
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, cache.poll())
+	})
+	mux.HandleFunc("GET /events", lb.serveEvents(cache))
+	mux.HandleFunc("POST /backends/drain", lb.serveDrain(true))
+	mux.HandleFunc("POST /backends/enable", lb.serveDrain(false))
+	mux.HandleFunc("GET /routes", lb.serveRoutes)
+	mux.HandleFunc("POST /routes/reload", lb.serveReloadRoutes)
+	mux.HandleFunc("GET /routes/splits", lb.serveSplits)
+	mux.HandleFunc("POST /routes/split", lb.serveSetSplit)
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+// Exige un token con permiso de administración si hay autenticación
+// configurada; sin ella exige la cabecera csrfHeader
+func (lb *LoadBalancer) authorizeAdmin(r *http.Request) (int, error) {
+	if lb.auth == nil {
+		if r.Header.Get(csrfHeader) == "" {
+			return http.StatusForbidden, fmt.Errorf("falta la cabecera %s", csrfHeader)
+		}
+		return http.StatusOK, nil
+	}
+	scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !strings.EqualFold(scheme, "bearer") {
+		token = ""
+	}
+	principal, err := lb.auth.Authenticate(strings.TrimSpace(token))
+	if err != nil {
+		return http.StatusUnauthorized, fmt.Errorf("autenticación fallida: %v", err)
+	}
+	if !principal.HasRole(auth.RoleAdmin) {
+		return http.StatusForbidden, fmt.Errorf("%s no tiene el permiso %s", principal.Subject, auth.RoleAdmin)
+	}
+	return http.StatusOK, nil
+}
+
+func (lb *LoadBalancer) serveDrain(drain bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if code, err := lb.authorizeAdmin(r); err != nil {
+			writeJSON(w, code, map[string]string{"error": err.Error()})
+			return
+		}
+		server := r.FormValue("server")
+		action := lb.Enable
+		if drain {
+			action = lb.Drain
+		}
+		if err := action(server); err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, lb.Backends())
+	}
+}
+
+func (lb *LoadBalancer) serveRoutes(w http.ResponseWriter, r *http.Request) {
+	routes := lb.Routes()
+	if routes == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "no hay reglas de enrutamiento configuradas"})
+		return
+	}
+	writeJSON(w, http.StatusOK, routes)
+}
+
+func (lb *LoadBalancer) serveReloadRoutes(w http.ResponseWriter, r *http.Request) {
+	if code, err := lb.authorizeAdmin(r); err != nil {
+		writeJSON(w, code, map[string]string{"error": err.Error()})
+		return
+	}
+	if err := lb.ReloadRoutes(); err != nil {
+		log.Printf("Error al recargar las reglas de enrutamiento: %v", err)
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, lb.Routes())
+}
+
+func (lb *LoadBalancer) serveSplits(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, lb.Splits())
+}
+
+// Cambia el peso de un pool: campos route, pool y weight
+func (lb *LoadBalancer) serveSetSplit(w http.ResponseWriter, r *http.Request) {
+	if code, err := lb.authorizeAdmin(r); err != nil {
+		writeJSON(w, code, map[string]string{"error": err.Error()})
+		return
+	}
+	weight, err := strconv.ParseFloat(r.FormValue("weight"), 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("peso inválido: %v", err)})
+		return
+	}
+	if err := lb.SetSplit(r.FormValue("route"), r.FormValue("pool"), weight); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, lb.Splits())
+}
+
+// Envía un evento SSE con el valor en JSON
+func writeEvent(w http.ResponseWriter, event string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+	return err
+}
+
+// Transmite el estado cada segundo y cada decisión de enrutamiento
+func (lb *LoadBalancer) serveEvents(cache *statusCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming no soportado", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+
+		decisions, cancel := lb.decisions.subscribe()
+		defer cancel()
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+
+		if err := writeEvent(w, "status", cache.get()); err != nil {
+			return
+		}
+		flusher.Flush()
+		for {
+			var err error
+			select {
+			case <-r.Context().Done():
+				return
+			case <-ticker.C:
+				err = writeEvent(w, "status", cache.get())
+			case d := <-decisions:
+				err = writeEvent(w, "decision", d)
+			}
+			if err != nil {
+				log.Printf("Panel: cliente desconectado: %v", err)
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package lb
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"Distributed_load_balancer/auth"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestDashboardCSRF(t *testing.T) {
+	handler := New(Config{Servers: []string{"backend-1"}}).DashboardHandler()
+	tests := []struct {
+		name   string
+		path   string
+		header bool
+		want   int
+	}{
+		{"drenar sin cabecera", "/backends/drain", false, http.StatusForbidden},
+		{"drenar con cabecera", "/backends/drain", true, http.StatusOK},
+		{"habilitar sin cabecera", "/backends/enable", false, http.StatusForbidden},
+		{"habilitar con cabecera", "/backends/enable", true, http.StatusOK},
+		{"recargar rutas sin cabecera", "/routes/reload", false, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{"server": {"backend-1"}}
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.header {
+				req.Header.Set(csrfHeader, "panel")
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("código %d, se esperaba %d: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
+
+func TestDashboardStatusIsCached(t *testing.T) {
+	lb := New(Config{Servers: []string{"backend-1"}})
+	handler := lb.DashboardHandler()
+	var bodies []string
+	for i := 0; i < 3; i++ {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("código %d", rec.Code)
+		}
+		bodies = append(bodies, rec.Body.String())
+	}
+	// Las consultas seguidas devuelven la misma instantánea, sin recalcularla
+	if bodies[0] != bodies[1] || bodies[1] != bodies[2] {
+		t.Errorf("cada consulta recalculó el estado")
+	}
+}
+
+func TestDashboardAdminAuth(t *testing.T) {
+	dir := t.TempDir()
+	keysFile := filepath.Join(dir, "keys.json")
+	os.WriteFile(keysFile, []byte(`{"keys": {"admin": {"roles": ["admin"]}, "cliente": {"roles": ["submit"]}}}`), 0600)
+	authenticator, err := auth.NewAuthenticator(keysFile, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		auth   *auth.Authenticator
+		header map[string]string
+		want   int
+	}{
+		{"sin autenticación y sin cabecera", nil, nil, http.StatusForbidden},
+		{"sin autenticación con cabecera", nil, map[string]string{csrfHeader: "panel"}, http.StatusOK},
+		{"sin autenticación con token", nil, map[string]string{"Authorization": "Bearer admin"}, http.StatusForbidden},
+		{"con autenticación y sin token", authenticator, map[string]string{csrfHeader: "panel"}, http.StatusUnauthorized},
+		{"token admin", authenticator, map[string]string{"Authorization": "Bearer admin"}, http.StatusOK},
+		{"token sin rol admin", authenticator, map[string]string{"Authorization": "Bearer cliente"}, http.StatusForbidden},
+		{"token desconocido", authenticator, map[string]string{"Authorization": "Bearer otro"}, http.StatusUnauthorized},
+		{"esquema distinto de bearer", authenticator, map[string]string{"Authorization": "Basic admin"}, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lb := New(Config{Servers: []string{"backend-1"}, Auth: tt.auth})
+			req := httptest.NewRequest(http.MethodPost, "/backends/drain", nil)
+			for key, value := range tt.header {
+				req.Header.Set(key, value)
+			}
+			if code, err := lb.authorizeAdmin(req); code != tt.want {
+				t.Errorf("código %d (%v), se esperaba %d", code, err, tt.want)
+			}
+		})
+	}
+}
+
+// Balanceador que cuenta las consultas de carga sin conectarse a nadie
+func newProbeCountingLB(cfg Config) (*LoadBalancer, *atomic.Int32) {
+	probes := &atomic.Int32{}
+	cfg.DialOptions = append(cfg.DialOptions, grpc.WithChainUnaryInterceptor(
+		func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			if strings.HasSuffix(method, "/GetLoad") {
+				probes.Add(1)
+			}
+			return status.Error(codes.Unavailable, "sin servidor")
+		}))
+	return New(cfg), probes
+}
+
+func TestRefreshStaleSkipsUnavailable(t *testing.T) {
+	tests := []struct {
+		name    string
+		disable func(lb *LoadBalancer)
+		want    int32
+	}{
+		{"disponible", func(*LoadBalancer) {}, 1},
+		{"drenado", func(lb *LoadBalancer) { lb.Drain("backend-1") }, 0},
+		{"circuito abierto", func(lb *LoadBalancer) {
+			lb.breakers.allow("backend-1")
+			lb.breakers.record("backend-1", true)
+		}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lb, probes := newProbeCountingLB(Config{
+				Servers:  []string{"backend-1"},
+				Breakers: &BreakersFile{Default: BreakerConfig{ConsecutiveFailures: 1, OpenMs: 60000}},
+			})
+			tt.disable(lb)
+			lb.RefreshStale(0)
+			if got := probes.Load(); got != tt.want {
+				t.Errorf("%d consultas de carga, se esperaban %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDashboardProbesOnlyWhileWatched(t *testing.T) {
+	lb, probes := newProbeCountingLB(Config{Servers: []string{"backend-1"}})
+	handler := lb.DashboardHandler()
+
+	// Sin nadie mirando el panel no se consulta la carga
+	time.Sleep(1500 * time.Millisecond)
+	if got := probes.Load(); got != 0 {
+		t.Fatalf("%d consultas de carga sin nadie mirando el panel", got)
+	}
+
+	// Una consulta a /status cuenta como alguien mirando
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/status", nil))
+	time.Sleep(1200 * time.Millisecond)
+	if probes.Load() == 0 {
+		t.Error("no se consultó la carga con el panel abierto")
+	}
+}
@@ -0,0 +1,65 @@
+package lb
+
+import (
+	"sync"
+	"time"
+)
+
+// Decisión de enrutamiento tomada por selectServer
+type Decision struct {
+	Time       time.Time           `json:"time"`
+	WorkID     int32               `json:"work_id"`
+	Tenant     string              `json:"tenant"`
+	Route      string              `json:"route,omitempty"`
+	Strategy   string              `json:"strategy"`
+	Selected   string              `json:"selected"`
+	Load       int32               `json:"load"`
+	Candidates []DecisionCandidate `json:"candidates"`
+}
+
+// Carga de un candidato al momento de decidir
+type DecisionCandidate struct {
+	Address     string  `json:"address"`
+	Load        int32   `json:"load"`
+	Utilization float64 `json:"utilization"`
+}
+
+// Difunde las decisiones a los suscriptores; los lentos pierden decisiones
+type decisionFeed struct {
+	mu   sync.Mutex
+	subs map[chan Decision]struct{}
+}
+
+func newDecisionFeed() *decisionFeed {
+	return &decisionFeed{subs: make(map[chan Decision]struct{})}
+}
+
+// Se suscribe a las decisiones; cancel libera la suscripción
+func (f *decisionFeed) subscribe() (<-chan Decision, func()) {
+	ch := make(chan Decision, 64)
+	f.mu.Lock()
+	f.subs[ch] = struct{}{}
+	f.mu.Unlock()
+	return ch, func() {
+		f.mu.Lock()
+		delete(f.subs, ch)
+		f.mu.Unlock()
+	}
+}
+
+func (f *decisionFeed) active() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.subs) > 0
+}
+
+func (f *decisionFeed) publish(d Decision) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for ch := range f.subs {
+		select {
+		case ch <- d:
+		default:
+		}
+	}
+}
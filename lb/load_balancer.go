@@ -0,0 +1,690 @@
+// Package lb implementa el balanceador de carga: recibe las solicitudes de
+// los clientes y las reenvía al servidor elegido según la carga observada.
+package lb
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"Distributed_load_balancer/auth"
+	"Distributed_load_balancer/balancer"
+	"Distributed_load_balancer/faults"
+	"Distributed_load_balancer/loadreport"
+	pb "Distributed_load_balancer/proto" // Asegúrate de que la ruta del paquete sea correcta
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type LoadBalancer struct {
+	pb.UnimplementedLoadBalancerServiceServer
+	servers       []string
+	mu            sync.Mutex
+	scheduler     *FairScheduler  // Reparto justo entre tenants
+	limits        *AdaptiveLimits // Límites de concurrencia adaptativos (nil = desactivados)
+	faults        *faults.Injector
+	strategy      balancer.Strategy // Estrategia de selección de servidor
+	dialOptions   []grpc.DialOption // Opciones extra para conectar con los servidores
+	responsesFile string            // CSV donde se registran las respuestas ("" = no se registran)
+	backends      *backendRegistry  // Salud, drenado y contadores por servidor
+	decisions     *decisionFeed     // Decisiones de enrutamiento para el panel
+	auth          *auth.Authenticator
+	affinity      *AffinityTable // Sesiones fijadas a un servidor (nil = sin afinidad)
+	routes        *Router        // Reglas de enrutamiento a pools (nil = sin reglas)
+	mirrorFile    string         // CSV donde se comparan las copias al pool sombra ("" = no se registran)
+	mirrorStats   MirrorStats
+	mirrorSlots   chan struct{} // Copias al pool sombra en curso
+	breakers      *Breakers     // Circuitos por servidor (nil = desactivados)
+	deadlines     DeadlineConfig
+	reportMaxAge  time.Duration // Antigüedad máxima de la carga informada en las respuestas (0 = siempre consultar)
+	watch         *WatchConfig  // Suscripción a la carga de los servidores (nil = desactivada)
+	watchMu       sync.Mutex
+	watching      map[string]bool // Servidores con suscripción en curso
+}
+
+// Plazos de las solicitudes
+type DeadlineConfig struct {
+	Default      time.Duration // Plazo si el cliente no envía uno (0 = sin plazo)
+	Max          time.Duration // Plazo máximo aunque el cliente pida más (0 = sin máximo)
+	Margin       time.Duration // Margen descontado al plazo que se propaga al servidor
+	ProbeTimeout time.Duration // Tiempo máximo de una consulta de carga (0 = 2s)
+}
+
+// Configuración del balanceador
+type Config struct {
+	Servers       []string
+	Tenants       *TenantsFile      // nil = todos los tenants con la configuración por defecto
+	MaxInFlight   int               // Solicitudes simultáneas hacia los servidores (0 = sin límite)
+	Strategy      balancer.Strategy // nil = least-load
+	Limits        *AdaptiveLimits   // nil = sin límite de concurrencia adaptativo
+	Faults        *faults.Injector  // nil = inyector sin reglas
+	DialOptions   []grpc.DialOption
+	ResponsesFile string
+	Auth          *auth.Authenticator // nil = acciones de administración HTTP sin autenticar
+	Affinity      *AffinityConfig     // nil = sin afinidad de sesión
+	Routes        *Router             // nil = todas las solicitudes al pool del tenant
+	MirrorFile    string
+	Breakers      *BreakersFile    // nil = sin circuitos
+	SlowStart     *SlowStartConfig // nil = sin arranque lento
+	Deadlines     DeadlineConfig   // Plazos por defecto; las rutas pueden cambiarlos
+	LoadReportAge time.Duration    // Usar la carga informada en las respuestas si tiene menos de esto (0 = siempre consultar GetLoad)
+	Watch         *WatchConfig     // nil = sin suscripción a la carga de los servidores
+}
+
+// Crea un balanceador con la configuración dada
+func New(cfg Config) *LoadBalancer {
+	if cfg.Strategy == nil {
+		cfg.Strategy = &balancer.LeastLoad{}
+	}
+	if cfg.Faults == nil {
+		cfg.Faults = faults.NewInjector("Balanceador", nil)
+	}
+	lb := &LoadBalancer{
+		servers:       cfg.Servers,
+		scheduler:     NewFairScheduler(cfg.Tenants, cfg.MaxInFlight),
+		limits:        cfg.Limits,
+		faults:        cfg.Faults,
+		strategy:      cfg.Strategy,
+		dialOptions:   cfg.DialOptions,
+		responsesFile: cfg.ResponsesFile,
+		backends:      newBackendRegistry(cfg.Servers),
+		decisions:     newDecisionFeed(),
+		auth:          cfg.Auth,
+		routes:        cfg.Routes,
+		mirrorFile:    cfg.MirrorFile,
+		mirrorSlots:   make(chan struct{}, maxMirrorInFlight),
+		deadlines:     cfg.Deadlines,
+		reportMaxAge:  cfg.LoadReportAge,
+		watch:         cfg.Watch,
+		watching:      make(map[string]bool),
+	}
+	if lb.deadlines.ProbeTimeout <= 0 {
+		lb.deadlines.ProbeTimeout = 2 * time.Second
+	}
+	if cfg.Breakers != nil {
+		lb.breakers = NewBreakers(cfg.Breakers)
+	}
+	// Los servidores configurados al iniciar empiezan con peso completo
+	lb.backends.setSlowStart(cfg.SlowStart)
+	if cfg.Routes != nil {
+		lb.backends.add(cfg.Routes.Servers())
+	}
+	if cfg.Affinity != nil {
+		lb.affinity = NewAffinityTable(*cfg.Affinity)
+	}
+	// Con suscripción se balancea con la carga que empujan los servidores; si
+	// un reporte se atrasa más de tres intervalos se vuelve a consultar
+	if cfg.Watch != nil {
+		if lb.reportMaxAge <= 0 {
+			interval := cfg.Watch.Interval
+			if interval <= 0 {
+				interval = time.Second
+			}
+			lb.reportMaxAge = 3 * interval
+		}
+		lb.watchServers(lb.servers)
+		if cfg.Routes != nil {
+			lb.watchServers(cfg.Routes.Servers())
+		}
+	}
+	return lb
+}
+
+type ServerLoad struct {
+	balancer.Candidate
+	err error
+}
+
+var csvMutex sync.Mutex
+
+// Lee la lista de servidores desde el archivo
+func ReadServersFromFile(filename string) ([]string, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error al leer el archivo de servidores: %v", err)
+	}
+	servers := strings.Split(strings.TrimSpace(string(content)), "\n")
+	return servers, nil
+}
+
+// Abre una conexión con un servidor
+func (lb *LoadBalancer) dial(server string) (*grpc.ClientConn, error) {
+	options := []grpc.DialOption{grpc.WithInsecure(), grpc.WithChainUnaryInterceptor(lb.faults.UnaryClientInterceptor)}
+	return grpc.Dial(server, append(options, lb.dialOptions...)...)
+}
+
+// Obtiene la carga de un servidor específico sin pasar del plazo de ctx
+func (lb *LoadBalancer) getServerLoad(ctx context.Context, server string) (*pb.LoadResponse, error) {
+	conn, err := lb.dial(server)
+	if err != nil {
+		return nil, fmt.Errorf("error al conectar con servidor %s: %v", server, err)
+	}
+	defer conn.Close()
+
+	client := pb.NewLoadBalancerServiceClient(conn)
+	ctx, cancel := context.WithTimeout(ctx, lb.deadlines.ProbeTimeout)
+	defer cancel()
+
+	res, err := client.GetLoad(ctx, &pb.LoadRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener carga de %s: %v", server, err)
+	}
+	return res, nil
+}
+
+// Selecciona un servidor del pool dado (nil = todos) según la estrategia dada
+// (nil = la configurada); devuelve también los candidatos considerados
+func (lb *LoadBalancer) selectServer(ctx context.Context, pool []string, strategy balancer.Strategy) (balancer.Candidate, []balancer.Candidate, error) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	if len(pool) == 0 {
+		pool = lb.servers
+	}
+	if strategy == nil {
+		strategy = lb.strategy
+	}
+	// Los servidores drenados o con el circuito abierto no reciben solicitudes
+	// nuevas ni se les consulta la carga
+	pool = lb.breakers.ready(lb.backends.available(pool))
+
+	// Canal para recibir las cargas de los servidores
+	loadChan := make(chan ServerLoad, len(pool))
+
+	// Obtener la carga de cada servidor de forma concurrente; los que la
+	// informaron hace poco en una respuesta no se consultan
+	var wg sync.WaitGroup
+	var reported []balancer.Candidate
+	for _, server := range pool {
+		if lb.reportMaxAge > 0 {
+			if c, ok := lb.backends.fresh(server, lb.reportMaxAge); ok {
+				reported = append(reported, c)
+				continue
+			}
+		}
+		wg.Add(1)
+		go func(serverAddr string) {
+			defer wg.Done()
+			res, err := lb.getServerLoad(ctx, serverAddr)
+			if err != nil {
+				loadChan <- ServerLoad{Candidate: balancer.Candidate{Address: serverAddr}, err: err}
+				return
+			}
+			loadChan <- ServerLoad{Candidate: balancer.Candidate{
+				Address:     serverAddr,
+				Load:        res.Load,
+				Capacity:    res.Capacity,
+				QueueDepth:  res.QueueDepth,
+				Utilization: res.Utilization,
+			}}
+		}(server)
+	}
+
+	// Esperar en una goroutine separada a que terminen todas las consultas
+	go func() {
+		wg.Wait()
+		close(loadChan)
+	}()
+
+	// Reunir los servidores que respondieron
+	var candidates []balancer.Candidate
+	for _, c := range reported {
+		log.Printf("Servidor %s tiene carga informada: %d (capacidad: %d, en cola: %d, utilización: %.2f)",
+			c.Address, c.Load, c.Capacity, c.QueueDepth, c.Utilization)
+		candidates = append(candidates, c)
+	}
+	for serverLoad := range loadChan {
+		lb.backends.probed(serverLoad.Candidate, serverLoad.err)
+		if serverLoad.err == nil {
+			log.Printf("Servidor %s tiene carga: %d (capacidad: %d, en cola: %d, utilización: %.2f)",
+				serverLoad.Address, serverLoad.Load, serverLoad.Capacity, serverLoad.QueueDepth, serverLoad.Utilization)
+			candidates = append(candidates, serverLoad.Candidate)
+		} else {
+			log.Printf("Error al obtener carga de %s: %v", serverLoad.Address, serverLoad.err)
+		}
+	}
+
+	candidates = lb.backends.weigh(candidates)
+	if len(candidates) == 0 {
+		return balancer.Candidate{}, nil, fmt.Errorf("no hay servidores disponibles")
+	}
+
+	// Orden estable para que las estrategias por turno sean predecibles
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Address < candidates[j].Address })
+	selected := candidates[strategy.Pick(candidates)]
+	// Contar la solicitud antes de soltar el lock para que las selecciones
+	// concurrentes no vean la misma carga informada
+	lb.backends.forwarded(selected.Address)
+
+	log.Printf("Seleccionado servidor %s con carga %d (%s)", selected.Address, selected.Load, strategy.Name())
+	return selected, candidates, nil
+}
+
+// Procesa la solicitud de un cliente
+func (lb *LoadBalancer) ProcessRequest(ctx context.Context, req *pb.Request) (res *pb.Response, err error) {
+	tenant := tenantFromContext(ctx)
+	log.Printf("Recibida solicitud para trabajo %d (tenant %s)", req.WorkId, tenant)
+
+	// Latencia y resultado de la llamada al servidor para los limitadores
+	// adaptativos; sin muestra (sampled = false) el cupo se libera sin ajustar
+	// el límite, así los rechazos locales no lo hacen crecer
+	var rtt time.Duration
+	var dropped, sampled bool
+	releaseLimiter := func(limiter *ConcurrencyLimiter) {
+		if sampled {
+			limiter.Release(rtt, dropped)
+		} else {
+			limiter.Cancel()
+		}
+	}
+
+	// Descartar rápido si se superó el límite de concurrencia global
+	if limiter := lb.limits.Global(); limiter != nil {
+		if !limiter.TryAcquire() {
+			return nil, status.Errorf(codes.ResourceExhausted, "límite de concurrencia global alcanzado (%d)", limiter.Limit())
+		}
+		defer releaseLimiter(limiter)
+	}
+
+	// El plazo de la ruta cuenta desde que llega la solicitud, incluida la espera en cola
+	route := lb.route(ctx, req, tenant)
+	clientCtx := ctx // Sin el plazo que agrega el balanceador
+	ctx, cancel := lb.withDeadline(ctx, route)
+	defer cancel()
+
+	// Esperar turno según el reparto justo entre tenants
+	release, err := lb.scheduler.Acquire(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// Las sesiones con afinidad vuelven a su servidor mientras esté sano
+	var affinityKey, newSession string
+	if lb.affinity != nil {
+		affinityKey, newSession = lb.affinity.keyFromContext(ctx)
+	}
+	pool, strategy := route.Servers, route.Strategy
+	if pool == nil {
+		pool = lb.scheduler.Pool(tenant)
+	}
+	if strategy == nil {
+		strategy = lb.strategy
+	}
+	selected, sticky := lb.stickyServer(affinityKey, pool)
+	if sticky {
+		log.Printf("Sesión fijada al servidor %s", selected.Address)
+		lb.publishDecision(req.WorkId, tenant, route.Name, "affinity", selected, nil)
+	} else {
+		var candidates []balancer.Candidate
+		selected, candidates, err = lb.selectServer(ctx, pool, strategy)
+		if err != nil {
+			return nil, fmt.Errorf("error al seleccionar servidor: %v", err)
+		}
+		lb.publishDecision(req.WorkId, tenant, route.Name, strategy.Name(), selected, candidates)
+		if affinityKey != "" {
+			lb.affinity.Set(affinityKey, selected.Address)
+		}
+	}
+	server := selected.Address
+
+	// Guardar en el archivo CSV la respuesta o el error de la solicitud
+	if lb.responsesFile != "" {
+		defer func() {
+			go lb.saveToCSV(req.WorkId, server, selected.Load, res.GetResult(), status.Code(err), route.Name, route.Pool)
+		}()
+	}
+
+	// Fallar rápido si el plazo restante no alcanza para el servidor
+	backendCtx, cancelBackend, err := lb.backendDeadline(ctx, server)
+	if err != nil {
+		log.Printf("Trabajo %d descartado: %v", req.WorkId, err)
+		return nil, err
+	}
+	defer cancelBackend()
+
+	// Descartar rápido si se superó el límite de concurrencia del servidor
+	if limiter := lb.limits.Backend(server); limiter != nil {
+		if !limiter.TryAcquire() {
+			return nil, status.Errorf(codes.ResourceExhausted, "límite de concurrencia de %s alcanzado (%d)", server, limiter.Limit())
+		}
+		defer releaseLimiter(limiter)
+	}
+
+	// En semiabierto solo pasan unas pocas solicitudes de prueba
+	if !lb.breakers.allow(server) {
+		return nil, status.Errorf(codes.Unavailable, "circuito abierto para %s", server)
+	}
+
+	conn, err := lb.dial(server)
+	if err != nil {
+		lb.breakers.record(server, true)
+		sampled, dropped = true, true
+		return nil, fmt.Errorf("error al conectar con servidor %s: %v", server, err)
+	}
+	defer conn.Close()
+
+	// Copia opcional al pool sombra; el cliente no la espera
+	var mirrored chan mirrorOutcome
+	if route.mirror != nil && route.mirror.sample() && lb.acquireMirror() {
+		mirrored = make(chan mirrorOutcome, 1)
+		go lb.mirror(req, route.Name, route.mirror, mirrored)
+	}
+
+	client := pb.NewLoadBalancerServiceClient(conn)
+	var trailer metadata.MD
+	start := time.Now()
+	res, err = client.ProcessRequest(backendCtx, req, grpc.Trailer(&trailer))
+	rtt = time.Since(start)
+	if load, ok := loadreport.FromMetadata(trailer); ok {
+		lb.backends.loadReported(server, load)
+	}
+	if mirrored != nil {
+		mirrored <- mirrorOutcome{server: server, result: res.GetResult(), err: err, rtt: rtt}
+	}
+	// Falla del servidor si el cliente seguía esperando: incluye no responder
+	// dentro del plazo del balanceador o de la ruta; no cuenta si el cliente
+	// canceló o venció su propio plazo
+	sampled, dropped = true, err != nil && clientCtx.Err() == nil
+	lb.backends.record(server, rtt, err != nil)
+	if lb.breakers.record(server, dropped) {
+		lb.backends.recovered(server)
+	}
+	route.Record(rtt, dropped)
+	if err != nil {
+		// La sesión se reasigna en la próxima solicitud
+		if affinityKey != "" && dropped {
+			lb.affinity.Delete(affinityKey)
+		}
+		return nil, status.Errorf(status.Code(err), "error al procesar solicitud en servidor %s: %v", server, err)
+	}
+
+	log.Printf("Respuesta del servidor %s: %s", server, res.Result)
+
+	// Informar al cliente qué servidor atendió la solicitud y su sesión nueva
+	header := metadata.Pairs("x-backend", server)
+	if newSession != "" {
+		header.Set(sessionMetadataKey, newSession)
+	}
+	grpc.SetHeader(ctx, header)
+
+	return res, nil
+}
+
+// Guarda la respuesta en el archivo CSV del balanceador; code indica si la
+// solicitud falló (OK = respondida)
+func (lb *LoadBalancer) saveToCSV(workId int32, server string, load int32, result string, code codes.Code, route, pool string) {
+	csvMutex.Lock()
+	defer csvMutex.Unlock()
+
+	file, err := os.OpenFile(lb.responsesFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		log.Printf("Error al abrir el archivo CSV: %v", err)
+		return
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	// Si el archivo está vacío, escribir encabezado
+	fileInfo, err := file.Stat()
+	if err != nil {
+		log.Printf("Error al obtener información del archivo CSV: %v", err)
+		return
+	}
+
+	if fileInfo.Size() == 0 {
+		writer.Write([]string{"Timestamp", "TrabajoID", "Servidor", "Carga", "Resultado", "Ruta", "Pool", "Estado"})
+	}
+
+	// Escribir en el archivo CSV
+	record := []string{
+		time.Now().Format("2006/01/02 15:04:05"), // Fecha en formato YYYY/MM/DD HH:MM:SS
+		fmt.Sprintf("%d", workId),                // ID del trabajo
+		server,                                   // Servidor
+		fmt.Sprintf("%d", load),                  // Carga del servidor al asignarle el trabajo
+		result,                                   // Resultado del trabajo
+		route,                                    // Regla de enrutamiento aplicada ("" = sin reglas)
+		pool,                                     // Pool elegido por la regla o su reparto
+		code.String(),                            // Código gRPC de la solicitud (OK = respondida)
+	}
+
+	if err := writer.Write(record); err != nil {
+		log.Printf("Error al escribir en el archivo CSV: %v", err)
+	}
+}
+
+// Reemplaza en tiempo de ejecución las fallas inyectadas en el balanceador
+func (lb *LoadBalancer) SetFaults(ctx context.Context, cfg *pb.FaultConfig) (*pb.FaultConfig, error) {
+	lb.faults.Set(cfg)
+	return lb.faults.Config(), nil
+}
+
+// Registra periódicamente las estadísticas del balanceador en el log
+func (lb *LoadBalancer) LogStats(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		for _, s := range lb.scheduler.Stats() {
+			log.Printf("[Tenant %s] en curso: %d, en cola: %d, admitidas: %d, completadas: %d, rechazadas: %d, espera media: %v",
+				s.Name, s.InFlight, s.Queued, s.Admitted, s.Completed, s.Rejected, s.AvgWait)
+		}
+		for _, s := range lb.limits.Stats() {
+			log.Printf("[Límite %s] límite: %d, en curso: %d, última latencia: %v, aceptadas: %d, descartadas: %d, fallidas: %d",
+				s.Name, s.Limit, s.InFlight, s.LastRTT, s.Accepted, s.Shed, s.Dropped)
+		}
+		if s := lb.MirrorStats(); s.Mirrored > 0 {
+			log.Printf("[Sombra] copias: %d, coinciden: %d, difieren: %d, errores de la sombra: %d, errores del principal: %d",
+				s.Mirrored, s.Matched, s.Mismatched, s.ShadowErrors, s.PrimaryErrors)
+		}
+	}
+}
+
+// Aplica el plazo de la ruta: el del cliente acotado al máximo, o el plazo por
+// defecto si el cliente no envió ninguno
+func (lb *LoadBalancer) withDeadline(ctx context.Context, route RouteResult) (context.Context, context.CancelFunc) {
+	timeout, max := lb.deadlines.Default, lb.deadlines.Max
+	if route.Timeout > 0 {
+		timeout = route.Timeout
+	}
+	if route.MaxTimeout > 0 {
+		max = route.MaxTimeout
+	}
+	if timeout == 0 || (max > 0 && timeout > max) {
+		timeout = max
+	}
+	deadline, ok := ctx.Deadline()
+	switch {
+	case ok && max > 0 && time.Until(deadline) > max:
+		return context.WithTimeout(ctx, max)
+	case !ok && timeout > 0:
+		return context.WithTimeout(ctx, timeout)
+	}
+	return ctx, func() {}
+}
+
+// Contexto para la llamada al servidor con el plazo restante menos el margen;
+// falla si ese plazo ya venció o es menor que la latencia media del servidor
+func (lb *LoadBalancer) backendDeadline(ctx context.Context, server string) (context.Context, context.CancelFunc, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return ctx, func() {}, nil
+	}
+	deadline = deadline.Add(-lb.deadlines.Margin)
+	remaining := time.Until(deadline)
+	if remaining <= 0 {
+		return nil, nil, status.Errorf(codes.DeadlineExceeded, "plazo vencido antes de reenviar a %s", server)
+	}
+	if mean, ok := lb.backends.meanLatency(server); ok && remaining < mean {
+		return nil, nil, status.Errorf(codes.DeadlineExceeded, "plazo restante %v menor que la latencia media de %s (%v)",
+			remaining.Round(time.Millisecond), server, mean.Round(time.Millisecond))
+	}
+	backendCtx, cancel := context.WithDeadline(ctx, deadline)
+	return backendCtx, cancel, nil
+}
+
+// Ruta de la solicitud según las reglas (vacía si no hay reglas)
+func (lb *LoadBalancer) route(ctx context.Context, req *pb.Request, tenant string) RouteResult {
+	if lb.routes == nil {
+		return RouteResult{}
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	route := lb.routes.Match(RouteRequest{WorkType: req.WorkType, Tenant: tenant, WorkID: req.WorkId, Metadata: md})
+	if route.split != nil {
+		log.Printf("Trabajo %d por la ruta %s (reparto: pool %s)", req.WorkId, route.Name, route.Pool)
+	} else {
+		log.Printf("Trabajo %d por la ruta %s (pool %s)", req.WorkId, route.Name, route.Pool)
+	}
+	return route
+}
+
+// Vuelve a leer las reglas de enrutamiento; si fallan se conservan las vigentes
+func (lb *LoadBalancer) ReloadRoutes() error {
+	if lb.routes == nil {
+		return fmt.Errorf("no hay reglas de enrutamiento configuradas")
+	}
+	if err := lb.routes.Reload(); err != nil {
+		return err
+	}
+	lb.backends.add(lb.routes.Servers())
+	lb.watchServers(lb.routes.Servers())
+	log.Printf("Reglas de enrutamiento recargadas (%d reglas)", len(lb.routes.Config().Routes))
+	return nil
+}
+
+// Cambia en caliente el peso de un pool en el reparto de una ruta
+func (lb *LoadBalancer) SetSplit(route, pool string, weight float64) error {
+	if lb.routes == nil {
+		return fmt.Errorf("no hay reglas de enrutamiento configuradas")
+	}
+	if err := lb.routes.SetSplit(route, pool, weight); err != nil {
+		return err
+	}
+	log.Printf("Ruta %s: peso del pool %s cambiado a %g", route, pool, weight)
+	return nil
+}
+
+// Estado de los repartos entre pools (nil si no hay reglas)
+func (lb *LoadBalancer) Splits() []SplitStatus {
+	if lb.routes == nil {
+		return nil
+	}
+	return lb.routes.Splits()
+}
+
+// Reglas de enrutamiento vigentes (nil si no hay)
+func (lb *LoadBalancer) Routes() *RoutesFile {
+	if lb.routes == nil {
+		return nil
+	}
+	return lb.routes.Config()
+}
+
+// Servidor fijado a la sesión si sigue en el pool, sano y sin drenar
+func (lb *LoadBalancer) stickyServer(key string, pool []string) (balancer.Candidate, bool) {
+	if key == "" {
+		return balancer.Candidate{}, false
+	}
+	server, ok := lb.affinity.Get(key)
+	if !ok {
+		return balancer.Candidate{}, false
+	}
+	if len(pool) == 0 {
+		pool = lb.servers
+	}
+	inPool := false
+	for _, s := range pool {
+		inPool = inPool || s == server
+	}
+	if !inPool || len(lb.breakers.ready([]string{server})) == 0 {
+		return balancer.Candidate{}, false
+	}
+	selected, ok := lb.backends.usable(server)
+	if ok {
+		lb.backends.forwarded(server)
+	}
+	return selected, ok
+}
+
+// Publica la decisión de enrutamiento si hay alguien mirando el panel
+func (lb *LoadBalancer) publishDecision(workId int32, tenant, route, strategy string, selected balancer.Candidate, candidates []balancer.Candidate) {
+	if !lb.decisions.active() {
+		return
+	}
+	d := Decision{
+		Time:     time.Now(),
+		WorkID:   workId,
+		Tenant:   tenant,
+		Route:    route,
+		Strategy: strategy,
+		Selected: selected.Address,
+		Load:     selected.Load,
+	}
+	for _, c := range candidates {
+		d.Candidates = append(d.Candidates, DecisionCandidate{Address: c.Address, Load: c.Load, Utilization: c.Utilization})
+	}
+	lb.decisions.publish(d)
+}
+
+// Deja de enviar solicitudes nuevas al servidor; las que están en curso terminan
+func (lb *LoadBalancer) Drain(server string) error {
+	if err := lb.backends.setDraining(server, true); err != nil {
+		return err
+	}
+	log.Printf("Servidor %s drenado", server)
+	return nil
+}
+
+// Vuelve a enviar solicitudes al servidor
+func (lb *LoadBalancer) Enable(server string) error {
+	if err := lb.backends.setDraining(server, false); err != nil {
+		return err
+	}
+	log.Printf("Servidor %s habilitado", server)
+	return nil
+}
+
+// Estado de todos los servidores conocidos
+func (lb *LoadBalancer) Backends() []BackendStatus {
+	backends := lb.backends.status()
+	for i := range backends {
+		if s := lb.breakers.status(backends[i].Address); s != nil {
+			backends[i].Breaker = s
+			if s.State == BreakerOpen {
+				backends[i].Excluded = true
+			}
+		}
+	}
+	return backends
+}
+
+// Consulta la carga de los servidores que no se consultaron desde hace maxAge,
+// para que el estado no quede desactualizado cuando no hay tráfico
+func (lb *LoadBalancer) RefreshStale(maxAge time.Duration) {
+	var wg sync.WaitGroup
+	// Los servidores con el circuito abierto esperan su plazo sin consultas
+	for _, server := range lb.breakers.ready(lb.backends.stale(maxAge)) {
+		wg.Add(1)
+		go func(serverAddr string) {
+			defer wg.Done()
+			candidate := balancer.Candidate{Address: serverAddr}
+			res, err := lb.getServerLoad(context.Background(), serverAddr)
+			if err == nil {
+				candidate.Load, candidate.Capacity = res.Load, res.Capacity
+				candidate.QueueDepth, candidate.Utilization = res.QueueDepth, res.Utilization
+			}
+			lb.backends.probed(candidate, err)
+		}(server)
+	}
+	wg.Wait()
+}
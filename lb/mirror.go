@@ -0,0 +1,192 @@
+package lb
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"Distributed_load_balancer/loadreport"
+	pb "Distributed_load_balancer/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Tiempo máximo de una copia al pool sombra
+const mirrorTimeout = 10 * time.Second
+
+// Copias simultáneas al pool sombra; las que no entran se descartan para no
+// acumular goroutines si el pool sombra se vuelve lento
+const maxMirrorInFlight = 64
+
+// Copia de una parte del tráfico de una ruta a un pool sombra
+type MirrorConfig struct {
+	Pool    string  `json:"pool"`
+	Percent float64 `json:"percent"` // Porcentaje de solicitudes copiadas (0-100)
+}
+
+type mirrorTarget struct {
+	pool    string
+	servers []string
+	percent float64
+}
+
+// Decide al azar si se copia la solicitud
+func (m *mirrorTarget) sample() bool {
+	return rand.Float64()*100 < m.percent
+}
+
+// Resultado de la llamada al servidor principal, para compararlo con la copia
+type mirrorOutcome struct {
+	server string
+	result string
+	err    error
+	rtt    time.Duration
+}
+
+// Contadores de las copias al pool sombra
+type MirrorStats struct {
+	Mirrored      uint64 `json:"mirrored"`
+	Matched       uint64 `json:"matched"`
+	Mismatched    uint64 `json:"mismatched"`
+	ShadowErrors  uint64 `json:"shadow_errors"`  // La copia falló
+	PrimaryErrors uint64 `json:"primary_errors"` // Falló el principal: no hay con qué comparar
+	Dropped       uint64 `json:"dropped"`        // Descartadas por haber maxMirrorInFlight copias en curso
+}
+
+var mirrorCSVMutex sync.Mutex
+
+// Reserva el lugar de una copia; si ya hay maxMirrorInFlight en curso la descarta
+func (lb *LoadBalancer) acquireMirror() bool {
+	select {
+	case lb.mirrorSlots <- struct{}{}:
+		return true
+	default:
+		atomic.AddUint64(&lb.mirrorStats.Dropped, 1)
+		return false
+	}
+}
+
+// Envía una copia de la solicitud a un servidor del pool sombra y registra si su
+// resultado coincide con el del principal, que llega por primary. El cliente
+// nunca espera por la copia. Libera el lugar reservado con acquireMirror.
+func (lb *LoadBalancer) mirror(req *pb.Request, route string, target *mirrorTarget, primary <-chan mirrorOutcome) {
+	defer func() { <-lb.mirrorSlots }()
+	servers := lb.breakers.ready(lb.backends.available(target.servers))
+	if len(servers) == 0 {
+		log.Printf("[Sombra] Pool %s sin servidores disponibles", target.pool)
+		return
+	}
+	server := servers[rand.Intn(len(servers))]
+	if !lb.breakers.allow(server) {
+		log.Printf("[Sombra] Circuito abierto para %s", server)
+		return
+	}
+	atomic.AddUint64(&lb.mirrorStats.Mirrored, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), mirrorTimeout)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, "x-mirror", "1")
+
+	var result string
+	start := time.Now()
+	conn, err := lb.dial(server)
+	if err == nil {
+		var res *pb.Response
+		var trailer metadata.MD
+		res, err = pb.NewLoadBalancerServiceClient(conn).ProcessRequest(ctx, req, grpc.Trailer(&trailer))
+		conn.Close()
+		if load, ok := loadreport.FromMetadata(trailer); ok {
+			lb.backends.loadReported(server, load)
+		}
+		if err == nil {
+			result = res.Result
+		}
+	}
+	rtt := time.Since(start)
+	lb.backends.record(server, rtt, err != nil)
+	if lb.breakers.record(server, err != nil) {
+		lb.backends.recovered(server)
+	}
+
+	p := <-primary
+	matched := err == nil && p.err == nil && result == p.result
+	switch {
+	case p.err != nil:
+		atomic.AddUint64(&lb.mirrorStats.PrimaryErrors, 1)
+	case err != nil:
+		atomic.AddUint64(&lb.mirrorStats.ShadowErrors, 1)
+		log.Printf("[Sombra] Trabajo %d: error en %s: %v", req.WorkId, server, err)
+	case matched:
+		atomic.AddUint64(&lb.mirrorStats.Matched, 1)
+	default:
+		atomic.AddUint64(&lb.mirrorStats.Mismatched, 1)
+		log.Printf("[Sombra] Trabajo %d: %s respondió %q y %s respondió %q", req.WorkId, p.server, p.result, server, result)
+	}
+
+	if lb.mirrorFile == "" {
+		return
+	}
+	errText := ""
+	if p.err != nil {
+		errText = "principal: " + p.err.Error()
+	} else if err != nil {
+		errText = "sombra: " + err.Error()
+	}
+	lb.saveMirrorToCSV([]string{
+		time.Now().Format("2006/01/02 15:04:05"),
+		fmt.Sprintf("%d", req.WorkId),
+		route,
+		p.server,
+		server,
+		strconv.FormatBool(matched),
+		p.result,
+		result,
+		fmt.Sprintf("%.3f", float64(p.rtt)/float64(time.Millisecond)),
+		fmt.Sprintf("%.3f", float64(rtt)/float64(time.Millisecond)),
+		errText,
+	})
+}
+
+// Agrega una comparación al registro de copias
+func (lb *LoadBalancer) saveMirrorToCSV(record []string) {
+	mirrorCSVMutex.Lock()
+	defer mirrorCSVMutex.Unlock()
+
+	file, err := os.OpenFile(lb.mirrorFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		log.Printf("Error al abrir el registro de copias: %v", err)
+		return
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	if info, err := file.Stat(); err == nil && info.Size() == 0 {
+		writer.Write([]string{"Timestamp", "TrabajoID", "Ruta", "Principal", "Sombra", "Coincide",
+			"ResultadoPrincipal", "ResultadoSombra", "LatenciaPrincipalMs", "LatenciaSombraMs", "Error"})
+	}
+	if err := writer.Write(record); err != nil {
+		log.Printf("Error al escribir en el registro de copias: %v", err)
+	}
+}
+
+// Contadores de las copias al pool sombra
+func (lb *LoadBalancer) MirrorStats() MirrorStats {
+	return MirrorStats{
+		Mirrored:      atomic.LoadUint64(&lb.mirrorStats.Mirrored),
+		Matched:       atomic.LoadUint64(&lb.mirrorStats.Matched),
+		Mismatched:    atomic.LoadUint64(&lb.mirrorStats.Mismatched),
+		ShadowErrors:  atomic.LoadUint64(&lb.mirrorStats.ShadowErrors),
+		PrimaryErrors: atomic.LoadUint64(&lb.mirrorStats.PrimaryErrors),
+		Dropped:       atomic.LoadUint64(&lb.mirrorStats.Dropped),
+	}
+}
@@ -0,0 +1,59 @@
+package lb
+
+import (
+	"testing"
+
+	pb "Distributed_load_balancer/proto"
+)
+
+func TestMirrorSlots(t *testing.T) {
+	lb := New(Config{})
+	for i := 0; i < maxMirrorInFlight; i++ {
+		if !lb.acquireMirror() {
+			t.Fatalf("copia %d descartada con lugares libres", i+1)
+		}
+	}
+	if lb.acquireMirror() {
+		t.Fatal("se aceptó una copia con todos los lugares ocupados")
+	}
+	if got := lb.MirrorStats().Dropped; got != 1 {
+		t.Errorf("descartadas = %d, se esperaba 1", got)
+	}
+	<-lb.mirrorSlots
+	if !lb.acquireMirror() {
+		t.Error("no se aceptó una copia tras liberar un lugar")
+	}
+}
+
+func TestMirrorSkipsOpenBreaker(t *testing.T) {
+	tests := []struct {
+		name     string
+		open     bool
+		mirrored uint64
+	}{
+		{"circuito abierto", true, 0},
+		{"circuito cerrado", false, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Dirección sin servidor: la copia falla al conectar si se intenta
+			shadow := "127.0.0.1:1"
+			lb := New(Config{Servers: []string{shadow}, Breakers: &BreakersFile{Default: BreakerConfig{ConsecutiveFailures: 1}}})
+			if tt.open {
+				lb.breakers.record(shadow, true)
+			}
+			primary := make(chan mirrorOutcome, 1)
+			primary <- mirrorOutcome{server: "principal", result: "ok"}
+			if !lb.acquireMirror() {
+				t.Fatal("copia descartada")
+			}
+			lb.mirror(&pb.Request{WorkId: 1}, "r", &mirrorTarget{pool: "sombra", servers: []string{shadow}, percent: 100}, primary)
+			if got := lb.MirrorStats().Mirrored; got != tt.mirrored {
+				t.Errorf("copias = %d, se esperaba %d", got, tt.mirrored)
+			}
+			if len(lb.mirrorSlots) != 0 {
+				t.Error("la copia no liberó su lugar")
+			}
+		})
+	}
+}
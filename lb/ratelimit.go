@@ -0,0 +1,293 @@
+package lb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"Distributed_load_balancer/auth"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Clave de metadata con la API key del cliente
+const apiKeyMetadataKey = "x-api-key"
+
+// Tiempo que un bucket lleno puede estar sin uso antes de descartarse
+const bucketIdleTTL = 5 * time.Minute
+
+// Bucket compartido por los tenants y API keys sin configuración propia
+const sharedBucket = "default"
+
+// Límite de tasa: tokens por segundo y tamaño de ráfaga
+type RateLimit struct {
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+}
+
+// Límite para una clase de clave, con excepciones por valor concreto
+type RateLimitClass struct {
+	RateLimit
+	Overrides map[string]RateLimit `json:"overrides"`
+}
+
+// Archivo de configuración de límites de tasa
+type RateLimitsFile struct {
+	Peer   *RateLimitClass `json:"peer"`    // Por dirección IP del cliente
+	Tenant *RateLimitClass `json:"tenant"`  // Por tenant configurado o del token; los demás comparten un bucket
+	APIKey *RateLimitClass `json:"api_key"` // Por identidad autenticada; sin autenticación, por API key con override o en un bucket compartido
+}
+
+// Lee la configuración de límites de tasa desde un archivo JSON
+func ReadRateLimitsFromFile(filename string) (*RateLimitsFile, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error al leer el archivo de límites: %v", err)
+	}
+	var cfg RateLimitsFile
+	if err := json.Unmarshal(content, &cfg); err != nil {
+		return nil, fmt.Errorf("error al interpretar el archivo de límites: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Comprueba que ningún límite quede sin reposición: un burst con rate 0 se
+// agotaría para siempre
+func (cfg *RateLimitsFile) Validate() error {
+	for name, class := range map[string]*RateLimitClass{"peer": cfg.Peer, "tenant": cfg.Tenant, "api_key": cfg.APIKey} {
+		if class == nil {
+			continue
+		}
+		if class.Rate <= 0 && class.Burst > 0 {
+			return fmt.Errorf("límite %s: burst %d sin rate positivo nunca se repone", name, class.Burst)
+		}
+		for key, l := range class.Overrides {
+			if l.Rate <= 0 && l.Burst > 0 {
+				return fmt.Errorf("límite %s=%s: burst %d sin rate positivo nunca se repone", name, key, l.Burst)
+			}
+		}
+	}
+	return nil
+}
+
+// Bucket de tokens para una clave
+type tokenBucket struct {
+	limit  RateLimit
+	tokens float64
+	last   time.Time // Última reposición
+	used   time.Time // Último consumo
+}
+
+// Repone tokens según el tiempo transcurrido
+func (b *tokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(b.last).Seconds()
+	b.tokens = math.Min(float64(b.limit.Burst), b.tokens+elapsed*b.limit.Rate)
+	b.last = now
+}
+
+// Intenta consumir un token; si no hay, indica cuánto esperar (0 si el
+// bucket no se repone)
+func (b *tokenBucket) take(now time.Time) (bool, time.Duration) {
+	b.refill(now)
+	b.used = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	if b.limit.Rate <= 0 {
+		return false, 0
+	}
+	wait := (1 - b.tokens) / b.limit.Rate
+	return false, time.Duration(wait * float64(time.Second))
+}
+
+// Conjunto de buckets de una clase de clave
+type bucketSet struct {
+	name    string
+	class   RateLimitClass
+	known   map[string]bool // Valores con bucket propio además de los overrides
+	buckets map[string]*tokenBucket
+}
+
+// Bucket que corresponde a un valor de clave. Los tenants y API keys que
+// elige el cliente sin estar configurados ni avalados por su token comparten
+// el bucket por defecto, como los tenants sin configurar en FairScheduler:
+// rotar valores no da una ráfaga nueva ni hace crecer el mapa
+func (bs *bucketSet) bucketKey(ctx context.Context, key string) string {
+	if _, ok := bs.class.Overrides[key]; ok || bs.known[key] {
+		return key
+	}
+	p := auth.FromContext(ctx)
+	switch bs.name {
+	case "tenant":
+		if p != nil && p.Tenant == key {
+			return key
+		}
+	case "api_key":
+		if p != nil {
+			return key
+		}
+	default:
+		return key
+	}
+	return sharedBucket
+}
+
+// Límite efectivo para un valor de clave
+func (bs *bucketSet) limitFor(key string) RateLimit {
+	if l, ok := bs.class.Overrides[key]; ok {
+		return l
+	}
+	return bs.class.RateLimit
+}
+
+// Limitador de tasa con buckets por peer, tenant y API key
+type RateLimiter struct {
+	mu      sync.Mutex
+	classes []*bucketSet
+}
+
+// Crea un limitador con las clases configuradas; los tenants del archivo de
+// tenants (nil = ninguno) tienen bucket propio aunque no tengan override
+func NewRateLimiter(config *RateLimitsFile, tenants *TenantsFile) *RateLimiter {
+	rl := &RateLimiter{}
+	add := func(name string, class *RateLimitClass, known map[string]bool) {
+		if class != nil {
+			rl.classes = append(rl.classes, &bucketSet{name: name, class: *class, known: known, buckets: make(map[string]*tokenBucket)})
+		}
+	}
+	knownTenants := make(map[string]bool)
+	if tenants != nil {
+		for name := range tenants.Tenants {
+			knownTenants[name] = true
+		}
+	}
+	add("peer", config.Peer, nil)
+	add("tenant", config.Tenant, knownTenants)
+	add("api_key", config.APIKey, nil)
+	go rl.evictIdle()
+	return rl
+}
+
+// Obtiene la clave de la solicitud para una clase ("" si no aplica)
+func rateLimitKey(ctx context.Context, class string) string {
+	switch class {
+	case "peer":
+		p, ok := peer.FromContext(ctx)
+		if !ok {
+			return ""
+		}
+		host, _, err := net.SplitHostPort(p.Addr.String())
+		if err != nil {
+			return p.Addr.String()
+		}
+		return host
+	case "tenant":
+		return tenantFromContext(ctx)
+	case "api_key":
+		// Con autenticación se limita por identidad, sea API key o JWT; sin
+		// ella, por el valor de x-api-key
+		if p := auth.FromContext(ctx); p != nil {
+			return p.Subject
+		}
+		md, ok := metadata.FromIncomingContext(ctx)
+		if !ok {
+			return ""
+		}
+		if values := md.Get(apiKeyMetadataKey); len(values) > 0 {
+			return strings.TrimSpace(values[0])
+		}
+	}
+	return ""
+}
+
+// Comprueba todas las clases; devuelve la clase que limitó y el tiempo de espera
+func (rl *RateLimiter) Allow(ctx context.Context) (string, time.Duration, bool) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+	var taken []*tokenBucket
+	for _, bs := range rl.classes {
+		key := rateLimitKey(ctx, bs.name)
+		if key == "" {
+			continue
+		}
+		key = bs.bucketKey(ctx, key)
+		limit := bs.limitFor(key)
+		if limit.Rate <= 0 && limit.Burst <= 0 {
+			continue // Sin límite para esta clave
+		}
+		if limit.Burst < 1 {
+			limit.Burst = 1
+		}
+		b, ok := bs.buckets[key]
+		if !ok {
+			b = &tokenBucket{limit: limit, tokens: float64(limit.Burst), last: now}
+			bs.buckets[key] = b
+		}
+		if ok, wait := b.take(now); !ok {
+			// Devolver los tokens ya consumidos en las otras clases
+			for _, t := range taken {
+				t.tokens++
+			}
+			return bs.name + "=" + key, wait, false
+		}
+		taken = append(taken, b)
+	}
+	return "", 0, true
+}
+
+// Descarta periódicamente los buckets llenos que llevan tiempo sin uso
+func (rl *RateLimiter) evictIdle() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for range ticker.C {
+		rl.mu.Lock()
+		now := time.Now()
+		for _, bs := range rl.classes {
+			for key, b := range bs.buckets {
+				b.refill(now)
+				if b.tokens >= float64(b.limit.Burst) && now.Sub(b.used) > bucketIdleTTL {
+					delete(bs.buckets, key)
+				}
+			}
+		}
+		rl.mu.Unlock()
+	}
+}
+
+// Interceptor unario que rechaza con RESOURCE_EXHAUSTED las solicitudes
+// limitadas; los métodos de administración no se limitan para poder operar
+// el balanceador aunque esté saturado
+func (rl *RateLimiter) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if auth.BalancerPolicy.Required(info.FullMethod) == auth.RoleAdmin {
+		return handler(ctx, req)
+	}
+	key, wait, ok := rl.Allow(ctx)
+	if !ok && wait <= 0 {
+		log.Printf("Solicitud limitada (%s) en %s, sin reposición", key, info.FullMethod)
+		return nil, status.Errorf(codes.ResourceExhausted, "límite de tasa excedido para %s", key)
+	}
+	if !ok {
+		retryAfter := fmt.Sprintf("%.3f", wait.Seconds())
+		grpc.SetTrailer(ctx, metadata.Pairs("retry-after", retryAfter))
+		log.Printf("Solicitud limitada (%s) en %s, reintentar en %ss", key, info.FullMethod, retryAfter)
+		return nil, status.Errorf(codes.ResourceExhausted, "límite de tasa excedido para %s, reintentar en %ss", key, retryAfter)
+	}
+	return handler(ctx, req)
+}
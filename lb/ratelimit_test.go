@@ -0,0 +1,208 @@
+package lb
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"Distributed_load_balancer/auth"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestTokenBucket(t *testing.T) {
+	tests := []struct {
+		name     string
+		limit    RateLimit
+		takes    int           // Consumos seguidos al inicio
+		elapsed  time.Duration // Tiempo antes del último consumo
+		wantOK   bool
+		wantWait time.Duration
+	}{
+		{"dentro del burst", RateLimit{Rate: 10, Burst: 3}, 2, 0, true, 0},
+		{"burst agotado", RateLimit{Rate: 10, Burst: 3}, 3, 0, false, 100 * time.Millisecond},
+		{"reposición parcial", RateLimit{Rate: 10, Burst: 3}, 3, 50 * time.Millisecond, false, 50 * time.Millisecond},
+		{"reposición completa", RateLimit{Rate: 10, Burst: 3}, 3, 100 * time.Millisecond, true, 0},
+		{"no pasa del burst", RateLimit{Rate: 10, Burst: 1}, 1, time.Hour, true, 0},
+		{"sin reposición", RateLimit{Rate: 0, Burst: 1}, 1, time.Hour, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			b := &tokenBucket{limit: tt.limit, tokens: float64(tt.limit.Burst), last: start}
+			for i := 0; i < tt.takes; i++ {
+				if ok, _ := b.take(start); !ok {
+					t.Fatalf("consumo %d rechazado dentro del burst", i+1)
+				}
+			}
+			ok, wait := b.take(start.Add(tt.elapsed))
+			if ok != tt.wantOK {
+				t.Errorf("ok = %v, se esperaba %v", ok, tt.wantOK)
+			}
+			if d := wait - tt.wantWait; d < -time.Millisecond || d > time.Millisecond {
+				t.Errorf("espera = %v, se esperaba %v", wait, tt.wantWait)
+			}
+		})
+	}
+}
+
+func TestRateLimiterRefundsOtherClasses(t *testing.T) {
+	rl := NewRateLimiter(&RateLimitsFile{
+		Tenant: &RateLimitClass{RateLimit: RateLimit{Rate: 1, Burst: 5}},
+		APIKey: &RateLimitClass{RateLimit: RateLimit{Rate: 1, Burst: 1}, Overrides: map[string]RateLimit{"k": {Rate: 1, Burst: 1}}},
+	}, &TenantsFile{Tenants: map[string]TenantConfig{"a": {}}})
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(tenantMetadataKey, "a", apiKeyMetadataKey, "k"))
+	if _, _, ok := rl.Allow(ctx); !ok {
+		t.Fatal("la primera solicitud debe pasar")
+	}
+	key, _, ok := rl.Allow(ctx)
+	if ok || key != "api_key=k" {
+		t.Fatalf("se esperaba el límite de api_key=k, se obtuvo %q (ok=%v)", key, ok)
+	}
+	// El token del tenant consumido en la solicitud rechazada se devuelve
+	if tokens := rl.classes[0].buckets["a"].tokens; tokens < 4 || tokens > 4.1 {
+		t.Errorf("tokens del tenant = %.2f, se esperaban 4", tokens)
+	}
+}
+
+func TestRateLimiterInterceptor(t *testing.T) {
+	rl := NewRateLimiter(&RateLimitsFile{Tenant: &RateLimitClass{RateLimit: RateLimit{Rate: 1, Burst: 1}}}, nil)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(tenantMetadataKey, "a"))
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+
+	tests := []struct {
+		method string
+		want   codes.Code
+	}{
+		{"/proto.LoadBalancerService/ProcessRequest", codes.OK},
+		{"/proto.LoadBalancerService/ProcessRequest", codes.ResourceExhausted},
+		{"/proto.LoadBalancerService/SetFaults", codes.OK}, // Administración: sin límite
+		{"/proto.LoadBalancerService/GetLoad", codes.ResourceExhausted},
+	}
+	for _, tt := range tests {
+		_, err := rl.UnaryInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+		if code := status.Code(err); code != tt.want {
+			t.Errorf("%s: código %v, se esperaba %v", tt.method, code, tt.want)
+		}
+	}
+}
+
+func TestRateLimitsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     RateLimitsFile
+		wantErr bool
+	}{
+		{"vacío", RateLimitsFile{}, false},
+		{"válido", RateLimitsFile{Peer: &RateLimitClass{RateLimit: RateLimit{Rate: 10, Burst: 20}}}, false},
+		{"sin límite", RateLimitsFile{Peer: &RateLimitClass{}}, false},
+		{"burst sin rate", RateLimitsFile{Peer: &RateLimitClass{RateLimit: RateLimit{Burst: 20}}}, true},
+		{"override sin rate", RateLimitsFile{Tenant: &RateLimitClass{
+			RateLimit: RateLimit{Rate: 10, Burst: 20},
+			Overrides: map[string]RateLimit{"a": {Burst: 5}},
+		}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, se esperaba error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRateLimitKeyAPIKey(t *testing.T) {
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "jwt.secret")
+	secret := []byte("secreto-de-prueba-de-32-bytes-o-mas")
+	os.WriteFile(secretFile, secret, 0600)
+	keysFile := filepath.Join(dir, "keys.json")
+	os.WriteFile(keysFile, []byte(`{"keys": {"clave-a": {"subject": "cliente-a", "roles": ["submit"]}}}`), 0600)
+	authenticator, err := auth.NewAuthenticator(keysFile, secretFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwt := func(subject string) string {
+		token, err := auth.SignJWT(auth.Claims{Subject: subject, Roles: []string{auth.RoleSubmit}}, secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	tests := []struct {
+		name string
+		auth bool
+		md   metadata.MD
+		want string
+	}{
+		{"API key autenticada", true, metadata.Pairs(apiKeyMetadataKey, "clave-a"), "cliente-a"},
+		{"JWT autenticado", true, metadata.Pairs("authorization", "Bearer "+jwt("cliente-b")), "cliente-b"},
+		{"JWT con x-api-key ajena", true, metadata.Pairs("authorization", "Bearer "+jwt("cliente-b"), apiKeyMetadataKey, "otra"), "cliente-b"},
+		{"sin autenticación", false, metadata.Pairs(apiKeyMetadataKey, "clave-a"), "clave-a"},
+		{"sin autenticación ni clave", false, metadata.MD{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				got = rateLimitKey(ctx, "api_key")
+				return nil, nil
+			}
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+			info := &grpc.UnaryServerInfo{FullMethod: "/proto.LoadBalancerService/ProcessRequest"}
+			if tt.auth {
+				if _, err := authenticator.UnaryInterceptor(auth.BalancerPolicy)(ctx, nil, info, handler); err != nil {
+					t.Fatal(err)
+				}
+			} else {
+				handler(ctx, nil)
+			}
+			if got != tt.want {
+				t.Errorf("clave %q, se esperaba %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimiterRotatedKeysShareBucket(t *testing.T) {
+	limit := RateLimitClass{RateLimit: RateLimit{Rate: 0.001, Burst: 1}}
+	tests := []struct {
+		name  string
+		cfg   RateLimitsFile
+		key   string // Clave de metadata que rota el cliente
+		known string // Valor con bucket propio
+	}{
+		{"tenant", RateLimitsFile{Tenant: &limit}, tenantMetadataKey, "configurado"},
+		{"api_key", RateLimitsFile{APIKey: &RateLimitClass{RateLimit: limit.RateLimit, Overrides: map[string]RateLimit{"conocida": limit.RateLimit}}}, apiKeyMetadataKey, "conocida"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rl := NewRateLimiter(&tt.cfg, &TenantsFile{Tenants: map[string]TenantConfig{"configurado": {}}})
+			allow := func(value string) bool {
+				_, _, ok := rl.Allow(metadata.NewIncomingContext(context.Background(), metadata.Pairs(tt.key, value)))
+				return ok
+			}
+			if !allow("rotado-0") {
+				t.Fatal("la primera solicitud debe pasar")
+			}
+			for i := 1; i < 100; i++ {
+				if allow(fmt.Sprintf("rotado-%d", i)) {
+					t.Fatalf("el valor rotado %d obtuvo una ráfaga nueva", i)
+				}
+			}
+			if !allow(tt.known) {
+				t.Error("el valor configurado debe tener su propio bucket")
+			}
+			if n := len(rl.classes[0].buckets); n != 2 {
+				t.Errorf("%d buckets, se esperaban 2", n)
+			}
+		})
+	}
+}
@@ -0,0 +1,323 @@
+package lb
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"Distributed_load_balancer/balancer"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// Condiciones de una ruta; los campos vacíos coinciden con todo
+type RouteMatch struct {
+	WorkType  string            `json:"work_type,omitempty"`
+	Tenant    string            `json:"tenant,omitempty"`
+	MinWorkID int32             `json:"min_work_id,omitempty"` // Rango de work_id (0 y 0 = todos)
+	MaxWorkID int32             `json:"max_work_id,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"` // Valor exacto de la metadata ("*" = presente)
+}
+
+// Regla de enrutamiento: las solicitudes que coinciden van al pool con la estrategia dada
+type Route struct {
+	Name     string          `json:"name"`
+	Match    RouteMatch      `json:"match"`
+	Pool     string          `json:"pool,omitempty"`     // Vacío = el pool del tenant o todos los servidores
+	Split    []SplitTarget   `json:"split,omitempty"`    // Reparto ponderado entre pools (en lugar de pool)
+	Rollback *RollbackConfig `json:"rollback,omitempty"` // Reversión automática del canario del reparto
+	Mirror   *MirrorConfig   `json:"mirror,omitempty"`   // Copia de parte del tráfico a un pool sombra
+	Strategy string          `json:"strategy,omitempty"` // Vacío = la estrategia del balanceador
+	// Plazos de la ruta en ms (0 = los del balanceador): el que se aplica si el
+	// cliente no envía uno y el máximo que se respeta
+	TimeoutMs    int64 `json:"timeout_ms,omitempty"`
+	MaxTimeoutMs int64 `json:"max_timeout_ms,omitempty"`
+}
+
+// Archivo de rutas: pools con nombre, reglas evaluadas en orden y ruta por defecto
+type RoutesFile struct {
+	Pools   map[string][]string `json:"pools"`
+	Routes  []Route             `json:"routes"`
+	Default Route               `json:"default"`
+}
+
+// Lee las rutas desde un archivo JSON
+func ReadRoutesFromFile(filename string) (*RoutesFile, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error al leer el archivo de rutas: %v", err)
+	}
+	var cfg RoutesFile
+	if err := json.Unmarshal(content, &cfg); err != nil {
+		return nil, fmt.Errorf("error al interpretar el archivo de rutas: %v", err)
+	}
+	return &cfg, nil
+}
+
+// Atributos de una solicitud que pueden usar las rutas
+type RouteRequest struct {
+	WorkType string
+	Tenant   string
+	WorkID   int32
+	Metadata metadata.MD
+}
+
+func (m RouteMatch) matches(r RouteRequest) bool {
+	if m.WorkType != "" && m.WorkType != r.WorkType {
+		return false
+	}
+	if m.Tenant != "" && m.Tenant != r.Tenant {
+		return false
+	}
+	if (m.MinWorkID != 0 || m.MaxWorkID != 0) && (r.WorkID < m.MinWorkID || r.WorkID > m.MaxWorkID) {
+		return false
+	}
+	for key, want := range m.Headers {
+		values := r.Metadata.Get(key)
+		if len(values) == 0 || (want != "*" && values[0] != want) {
+			return false
+		}
+	}
+	return true
+}
+
+// Ruta elegida para una solicitud
+type RouteResult struct {
+	Name       string
+	Pool       string
+	Servers    []string          // nil = el pool del tenant o todos los servidores
+	Strategy   balancer.Strategy // nil = la estrategia del balanceador
+	Timeout    time.Duration     // 0 = el plazo por defecto del balanceador
+	MaxTimeout time.Duration     // 0 = el plazo máximo del balanceador
+	split      *splitState       // Reparto del que salió el pool (nil = sin reparto)
+	mirror     *mirrorTarget     // Pool sombra (nil = sin copia)
+}
+
+// Registra el resultado de la solicitud para la reversión automática del canario
+func (r RouteResult) Record(rtt time.Duration, failed bool) {
+	if r.split != nil {
+		r.split.record(r.Pool, rtt, failed)
+	}
+}
+
+// Ruta lista para evaluar, con su estrategia y su reparto propios
+type compiledRoute struct {
+	match  RouteMatch
+	result RouteResult
+	split  *splitState
+}
+
+// Resultado de la ruta para una solicitud, eligiendo el pool del reparto
+func (c *compiledRoute) resolve() RouteResult {
+	result := c.result
+	if c.split != nil {
+		result.Pool, result.Servers = c.split.pick()
+		result.split = c.split
+	}
+	return result
+}
+
+// Tabla de rutas recargable en caliente
+type Router struct {
+	mu       sync.RWMutex
+	filename string
+	config   *RoutesFile
+	routes   []compiledRoute // Las reglas y al final la ruta por defecto
+}
+
+// Crea una tabla de rutas a partir del archivo
+func NewRouter(filename string) (*Router, error) {
+	r := &Router{filename: filename}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Vuelve a leer el archivo de rutas; si es inválido se conservan las rutas actuales.
+// Los repartos que no cambiaron en el archivo conservan sus pesos ajustados y
+// la reversión del canario; los demás vuelven a los pesos del archivo.
+func (r *Router) Reload() error {
+	cfg, err := ReadRoutesFromFile(r.filename)
+	if err != nil {
+		return err
+	}
+	routes, err := compileRoutes(cfg)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, c := range routes {
+		if c.split != nil {
+			c.split.inherit(r.split(c.result.Name))
+		}
+	}
+	r.config, r.routes = cfg, routes
+	return nil
+}
+
+func compileRoutes(cfg *RoutesFile) ([]compiledRoute, error) {
+	if cfg.Default.Name == "" {
+		cfg.Default.Name = "default"
+	}
+	var routes []compiledRoute
+	for i, route := range append(append([]Route{}, cfg.Routes...), cfg.Default) {
+		if route.Name == "" {
+			route.Name = fmt.Sprintf("ruta-%d", i+1)
+		}
+		if route.TimeoutMs < 0 || route.MaxTimeoutMs < 0 {
+			return nil, fmt.Errorf("ruta %s: plazo negativo", route.Name)
+		}
+		result := RouteResult{
+			Name:       route.Name,
+			Pool:       route.Pool,
+			Timeout:    time.Duration(route.TimeoutMs) * time.Millisecond,
+			MaxTimeout: time.Duration(route.MaxTimeoutMs) * time.Millisecond,
+		}
+		compiled := compiledRoute{}
+		if route.Pool != "" && len(route.Split) > 0 {
+			return nil, fmt.Errorf("la ruta %s indica pool y reparto a la vez", route.Name)
+		}
+		if route.Pool != "" {
+			servers, err := poolServers(cfg, route.Name, route.Pool)
+			if err != nil {
+				return nil, err
+			}
+			result.Servers = servers
+		}
+		if len(route.Split) > 0 {
+			var targets []splitTarget
+			for _, t := range route.Split {
+				servers, err := poolServers(cfg, route.Name, t.Pool)
+				if err != nil {
+					return nil, err
+				}
+				targets = append(targets, splitTarget{pool: t.Pool, servers: servers, weight: t.Weight})
+			}
+			split, err := newSplitState(route.Name, targets, route.Rollback)
+			if err != nil {
+				return nil, err
+			}
+			compiled.split = split
+		} else if route.Rollback != nil {
+			return nil, fmt.Errorf("la ruta %s tiene reversión pero no reparto", route.Name)
+		}
+		if m := route.Mirror; m != nil {
+			servers, err := poolServers(cfg, route.Name, m.Pool)
+			if err != nil {
+				return nil, err
+			}
+			if m.Percent < 0 || m.Percent > 100 {
+				return nil, fmt.Errorf("ruta %s: porcentaje de copia inválido (%g)", route.Name, m.Percent)
+			}
+			result.mirror = &mirrorTarget{pool: m.Pool, servers: servers, percent: m.Percent}
+		}
+		if route.Strategy != "" {
+			// Cada estrategia con su generador: un rand.Rand no admite uso concurrente
+			rng := rand.New(rand.NewSource(time.Now().UnixNano() + int64(i)))
+			strategy, err := balancer.New(route.Strategy, rng)
+			if err != nil {
+				return nil, fmt.Errorf("ruta %s: %v", route.Name, err)
+			}
+			result.Strategy = strategy
+		}
+		if m := route.Match; m.MinWorkID > m.MaxWorkID {
+			return nil, fmt.Errorf("ruta %s: rango de work_id inválido (%d-%d)", route.Name, m.MinWorkID, m.MaxWorkID)
+		}
+		match := route.Match
+		match.Headers = make(map[string]string, len(route.Match.Headers))
+		for key, value := range route.Match.Headers {
+			match.Headers[strings.ToLower(key)] = value
+		}
+		compiled.match, compiled.result = match, result
+		routes = append(routes, compiled)
+	}
+	return routes, nil
+}
+
+// Servidores de un pool con nombre
+func poolServers(cfg *RoutesFile, route, pool string) ([]string, error) {
+	servers, ok := cfg.Pools[pool]
+	if !ok {
+		return nil, fmt.Errorf("la ruta %s usa el pool desconocido %s", route, pool)
+	}
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("el pool %s no tiene servidores", pool)
+	}
+	return servers, nil
+}
+
+// Reparto vigente de la ruta (nil si no existe o no tiene reparto)
+func (r *Router) split(route string) *splitState {
+	for _, c := range r.routes {
+		if c.result.Name == route {
+			return c.split
+		}
+	}
+	return nil
+}
+
+// Primera ruta que coincide con la solicitud (la de por defecto si ninguna)
+func (r *Router) Match(req RouteRequest) RouteResult {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	last := len(r.routes) - 1
+	for i := range r.routes[:last] {
+		if r.routes[i].match.matches(req) {
+			return r.routes[i].resolve()
+		}
+	}
+	return r.routes[last].resolve()
+}
+
+// Cambia en caliente el peso de un pool en el reparto de una ruta
+func (r *Router) SetSplit(route, pool string, weight float64) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, c := range r.routes {
+		if c.result.Name != route {
+			continue
+		}
+		if c.split == nil {
+			return fmt.Errorf("la ruta %s no tiene reparto", route)
+		}
+		return c.split.setWeight(pool, weight)
+	}
+	return fmt.Errorf("ruta desconocida: %s", route)
+}
+
+// Estado de los repartos de todas las rutas
+func (r *Router) Splits() []SplitStatus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []SplitStatus
+	for _, c := range r.routes {
+		if c.split != nil {
+			out = append(out, c.split.status()...)
+		}
+	}
+	return out
+}
+
+// Configuración vigente
+func (r *Router) Config() *RoutesFile {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.config
+}
+
+// Todos los servidores de los pools
+func (r *Router) Servers() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var servers []string
+	for _, pool := range r.config.Pools {
+		servers = append(servers, pool...)
+	}
+	return servers
+}
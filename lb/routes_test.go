@@ -0,0 +1,119 @@
+package lb
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"Distributed_load_balancer/balancer"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// Escribe el archivo de rutas en un directorio temporal y crea la tabla
+func newTestRouter(t *testing.T, cfg *RoutesFile) (*Router, string) {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "routes.json")
+	writeRoutes(t, filename, cfg)
+	r, err := NewRouter(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r, filename
+}
+
+func writeRoutes(t *testing.T, filename string, cfg *RoutesFile) {
+	t.Helper()
+	content, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filename, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRouterMatch(t *testing.T) {
+	router, _ := newTestRouter(t, &RoutesFile{
+		Pools: map[string][]string{"gpu": {"g1"}, "batch": {"b1", "b2"}, "vip": {"v1"}, "beta": {"x1"}},
+		Routes: []Route{
+			{Name: "gpu", Match: RouteMatch{WorkType: "render"}, Pool: "gpu", Strategy: "p2c"},
+			{Name: "vip", Match: RouteMatch{Tenant: "acme", Headers: map[string]string{"X-Plan": "gold"}}, Pool: "vip"},
+			{Name: "beta", Match: RouteMatch{Headers: map[string]string{"x-beta": "*"}}, Pool: "beta"},
+			{Name: "lote", Match: RouteMatch{MinWorkID: 1000, MaxWorkID: 1999}, Pool: "batch", TimeoutMs: 500},
+		},
+	})
+	tests := []struct {
+		name  string
+		req   RouteRequest
+		route string
+		pool  string
+	}{
+		{"tipo de trabajo", RouteRequest{WorkType: "render"}, "gpu", "gpu"},
+		{"tenant y cabecera", RouteRequest{Tenant: "acme", Metadata: metadata.Pairs("x-plan", "gold")}, "vip", "vip"},
+		{"tenant sin la cabecera", RouteRequest{Tenant: "acme"}, "default", ""},
+		{"cabecera con otro valor", RouteRequest{Tenant: "acme", Metadata: metadata.Pairs("x-plan", "free")}, "default", ""},
+		{"cabecera presente", RouteRequest{Metadata: metadata.Pairs("x-beta", "1")}, "beta", "beta"},
+		{"inicio del rango", RouteRequest{WorkID: 1000}, "lote", "batch"},
+		{"fin del rango", RouteRequest{WorkID: 1999}, "lote", "batch"},
+		{"fuera del rango", RouteRequest{WorkID: 2000}, "default", ""},
+		{"primera que coincide", RouteRequest{WorkType: "render", WorkID: 1500}, "gpu", "gpu"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := router.Match(tt.req)
+			if got.Name != tt.route || got.Pool != tt.pool {
+				t.Errorf("ruta %s (pool %q), se esperaba %s (pool %q)", got.Name, got.Pool, tt.route, tt.pool)
+			}
+		})
+	}
+}
+
+func TestCompileRoutesErrors(t *testing.T) {
+	pools := map[string][]string{"a": {"a1"}, "vacío": {}}
+	tests := []struct {
+		name  string
+		route Route
+	}{
+		{"pool desconocido", Route{Pool: "b"}},
+		{"pool sin servidores", Route{Pool: "vacío"}},
+		{"pool y reparto", Route{Pool: "a", Split: []SplitTarget{{Pool: "a", Weight: 1}}}},
+		{"reversión sin reparto", Route{Pool: "a", Rollback: &RollbackConfig{Canary: "a"}}},
+		{"estrategia desconocida", Route{Strategy: "fastest"}},
+		{"rango invertido", Route{Match: RouteMatch{MinWorkID: 10, MaxWorkID: 5}}},
+		{"plazo negativo", Route{TimeoutMs: -1}},
+		{"copia inválida", Route{Mirror: &MirrorConfig{Pool: "a", Percent: 150}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := compileRoutes(&RoutesFile{Pools: pools, Routes: []Route{tt.route}}); err == nil {
+				t.Error("se esperaba un error")
+			}
+		})
+	}
+}
+
+// Las estrategias aleatorias de distintas rutas se usan a la vez sin
+// compartir el generador (go test -race)
+func TestRouteStrategiesConcurrent(t *testing.T) {
+	router, _ := newTestRouter(t, &RoutesFile{
+		Pools:   map[string][]string{"a": {"a1", "a2"}, "b": {"b1", "b2"}},
+		Routes:  []Route{{Name: "a", Match: RouteMatch{Tenant: "a"}, Pool: "a", Strategy: "random"}},
+		Default: Route{Pool: "b", Strategy: "p2c"},
+	})
+	candidates := []balancer.Candidate{{Address: "x"}, {Address: "y"}}
+	var wg sync.WaitGroup
+	for _, tenant := range []string{"a", "b", "a", "b"} {
+		wg.Add(1)
+		go func(tenant string) {
+			defer wg.Done()
+			strategy := router.Match(RouteRequest{Tenant: tenant}).Strategy
+			for i := 0; i < 1000; i++ {
+				strategy.Pick(candidates)
+			}
+		}(tenant)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,32 @@
+package lb
+
+import (
+	"math"
+	"time"
+)
+
+// Arranque lento: el peso de un servidor nuevo o recuperado sube de MinWeight
+// a 1 durante Window. Con Aggression 1 la subida es lineal; valores mayores
+// suben más rápido al principio y menores más despacio.
+type SlowStartConfig struct {
+	Window     time.Duration
+	MinWeight  float64 // Peso inicial (0 = 0.1)
+	Aggression float64 // Curvatura de la rampa (0 = 1, lineal)
+}
+
+// Peso efectivo a los elapsed de haber empezado el arranque lento
+func (c *SlowStartConfig) weight(elapsed time.Duration) float64 {
+	if c == nil || c.Window <= 0 || elapsed >= c.Window {
+		return 1
+	}
+	minWeight := c.MinWeight
+	if minWeight <= 0 {
+		minWeight = 0.1
+	}
+	aggression := c.Aggression
+	if aggression <= 0 {
+		aggression = 1
+	}
+	frac := math.Pow(float64(elapsed)/float64(c.Window), 1/aggression)
+	return math.Max(minWeight, math.Min(1, frac))
+}
@@ -0,0 +1,100 @@
+package lb
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"Distributed_load_balancer/balancer"
+)
+
+func TestSlowStartWeight(t *testing.T) {
+	window := 10 * time.Second
+	tests := []struct {
+		name    string
+		cfg     *SlowStartConfig
+		elapsed time.Duration
+		want    float64
+	}{
+		{"sin configuración", nil, 0, 1},
+		{"sin ventana", &SlowStartConfig{}, 0, 1},
+		{"al inicio usa el mínimo", &SlowStartConfig{Window: window}, 0, 0.1},
+		{"mínimo configurado", &SlowStartConfig{Window: window, MinWeight: 0.25}, time.Second, 0.25},
+		{"lineal a la mitad", &SlowStartConfig{Window: window}, window / 2, 0.5},
+		{"lineal al 80%", &SlowStartConfig{Window: window}, 8 * time.Second, 0.8},
+		{"agresivo sube antes", &SlowStartConfig{Window: window, Aggression: 2}, window / 4, 0.5},
+		{"suave sube después", &SlowStartConfig{Window: window, Aggression: 0.5}, window / 2, 0.25},
+		{"al terminar la ventana", &SlowStartConfig{Window: window}, window, 1},
+		{"después de la ventana", &SlowStartConfig{Window: window, Aggression: 0.5}, 2 * window, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.weight(tt.elapsed); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("weight(%v) = %.3f, se esperaba %.3f", tt.elapsed, got, tt.want)
+			}
+		})
+	}
+
+	// La curva nunca baja a medida que avanza el arranque
+	for _, aggression := range []float64{0.5, 1, 3} {
+		cfg := &SlowStartConfig{Window: window, Aggression: aggression}
+		prev := 0.0
+		for elapsed := time.Duration(0); elapsed <= window; elapsed += window / 20 {
+			w := cfg.weight(elapsed)
+			if w < prev {
+				t.Errorf("agresividad %v: el peso bajó de %.3f a %.3f en %v", aggression, prev, w, elapsed)
+			}
+			prev = w
+		}
+	}
+}
+
+func TestSlowStartRegistry(t *testing.T) {
+	r := newBackendRegistry([]string{"viejo", "estable", "drenado", "caído"})
+	r.setSlowStart(&SlowStartConfig{Window: time.Hour})
+	r.add([]string{"nuevo"})
+	r.recovered("viejo")
+	r.setDraining("drenado", true)
+	r.probed(balancer.Candidate{Address: "caído"}, errors.New("sin conexión"))
+
+	tests := []struct {
+		address  string
+		full     bool // Se espera peso completo
+		excluded bool // Se espera que weigh lo descarte
+	}{
+		{"viejo", false, false},  // Recuperado: vuelve a arrancar lento
+		{"nuevo", false, false},  // Agregado después de activar el arranque lento
+		{"otro", false, false},   // Desconocido: se agrega al consultarlo
+		{"estable", true, false}, // Conocido antes de activar el arranque lento
+		{"drenado", true, true},  // Conserva su peso pero no recibe solicitudes
+		{"caído", true, true},
+	}
+	candidates := make([]balancer.Candidate, len(tests))
+	for i, tt := range tests {
+		candidates[i].Address = tt.address
+	}
+	weighed := map[string]float64{}
+	for _, c := range r.weigh(candidates) {
+		weighed[c.Address] = c.Weight
+	}
+	status := map[string]BackendStatus{}
+	for _, s := range r.status() {
+		status[s.Address] = s
+	}
+	for _, tt := range tests {
+		w, ok := weighed[tt.address]
+		if ok == tt.excluded {
+			t.Errorf("%s: descartado %v, se esperaba %v", tt.address, !ok, tt.excluded)
+		}
+		if ok && (w >= 1) != tt.full {
+			t.Errorf("%s: peso %.3f", tt.address, w)
+		}
+		// El estado informa el peso aunque el servidor esté excluido: un peso
+		// 0 no se confunde con el peso completo de un candidato sin peso
+		s := status[tt.address]
+		if s.Excluded != tt.excluded || s.Weight <= 0 || (s.Weight >= 1) != tt.full {
+			t.Errorf("%s: estado con peso %.3f, excluido %v", tt.address, s.Weight, s.Excluded)
+		}
+	}
+}
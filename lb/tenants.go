@@ -0,0 +1,272 @@
+package lb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Clave de metadata con la que el cliente identifica a su tenant
+const tenantMetadataKey = "x-tenant"
+
+// Tenant usado cuando la solicitud no trae metadata
+const defaultTenant = "default"
+
+// Configuración de un tenant
+type TenantConfig struct {
+	Weight        int      `json:"weight"`         // Peso en el reparto DRR (mínimo 1)
+	MaxConcurrent int      `json:"max_concurrent"` // Solicitudes simultáneas permitidas (0 = sin límite)
+	MaxQueue      int      `json:"max_queue"`      // Solicitudes en espera permitidas (0 = sin límite)
+	Servers       []string `json:"servers"`        // Pool dedicado (vacío = todos los servidores)
+}
+
+// Archivo de configuración de tenants
+type TenantsFile struct {
+	Default TenantConfig            `json:"default"`
+	Tenants map[string]TenantConfig `json:"tenants"`
+}
+
+// Lee la configuración de tenants desde un archivo JSON
+func ReadTenantsFromFile(filename string) (*TenantsFile, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error al leer el archivo de tenants: %v", err)
+	}
+	var cfg TenantsFile
+	if err := json.Unmarshal(content, &cfg); err != nil {
+		return nil, fmt.Errorf("error al interpretar el archivo de tenants: %v", err)
+	}
+	return &cfg, nil
+}
+
+// Obtiene el tenant de la metadata de la solicitud
+func tenantFromContext(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return defaultTenant
+	}
+	values := md.Get(tenantMetadataKey)
+	if len(values) == 0 || strings.TrimSpace(values[0]) == "" {
+		return defaultTenant
+	}
+	return strings.TrimSpace(values[0])
+}
+
+// Solicitud esperando turno en la cola de un tenant
+type ticket struct {
+	ready   chan struct{}
+	granted bool
+	queued  time.Time
+}
+
+// Estado de la cola de un tenant
+type tenantQueue struct {
+	name     string
+	config   TenantConfig
+	deficit  int
+	waiting  []*ticket
+	inFlight int
+
+	// Estadísticas
+	admitted  int64
+	completed int64
+	rejected  int64
+	totalWait time.Duration
+}
+
+// Indica si el tenant puede despachar otra solicitud
+func (t *tenantQueue) eligible() bool {
+	if len(t.waiting) == 0 {
+		return false
+	}
+	return t.config.MaxConcurrent <= 0 || t.inFlight < t.config.MaxConcurrent
+}
+
+// Planificador deficit-round-robin entre tenants
+type FairScheduler struct {
+	mu       sync.Mutex
+	config   *TenantsFile
+	tenants  map[string]*tenantQueue
+	order    []string // Orden de visita del round robin
+	next     int
+	capacity int // Solicitudes simultáneas hacia los servidores (0 = sin límite)
+	inFlight int
+}
+
+// Crea un planificador con la configuración y capacidad global dadas
+func NewFairScheduler(config *TenantsFile, capacity int) *FairScheduler {
+	if config == nil {
+		config = &TenantsFile{}
+	}
+	return &FairScheduler{
+		config:   config,
+		tenants:  make(map[string]*tenantQueue),
+		capacity: capacity,
+	}
+}
+
+// Configuración efectiva de un tenant
+func (fs *FairScheduler) tenantConfig(name string) TenantConfig {
+	cfg, ok := fs.config.Tenants[name]
+	if !ok {
+		cfg = fs.config.Default
+	}
+	if cfg.Weight < 1 {
+		cfg.Weight = 1
+	}
+	return cfg
+}
+
+// Pool de servidores dedicado al tenant (nil si usa todos)
+func (fs *FairScheduler) Pool(name string) []string {
+	return fs.tenantConfig(name).Servers
+}
+
+// Obtiene o crea la cola de un tenant; los tenants que no están en la
+// configuración comparten la cola por defecto, así un cliente no puede
+// evadir los límites ni agrandar el planificador inventando nombres
+func (fs *FairScheduler) queueLocked(name string) *tenantQueue {
+	if _, ok := fs.config.Tenants[name]; !ok {
+		name = defaultTenant
+	}
+	t, ok := fs.tenants[name]
+	if !ok {
+		t = &tenantQueue{name: name, config: fs.tenantConfig(name)}
+		fs.tenants[name] = t
+		fs.order = append(fs.order, name)
+	}
+	return t
+}
+
+// Espera turno para el tenant y devuelve la función que libera el cupo
+func (fs *FairScheduler) Acquire(ctx context.Context, name string) (func(), error) {
+	fs.mu.Lock()
+	t := fs.queueLocked(name)
+	if t.config.MaxQueue > 0 && len(t.waiting) >= t.config.MaxQueue {
+		t.rejected++
+		fs.mu.Unlock()
+		return nil, status.Errorf(codes.ResourceExhausted, "cola del tenant %s llena", t.name)
+	}
+	tk := &ticket{ready: make(chan struct{}), queued: time.Now()}
+	t.waiting = append(t.waiting, tk)
+	fs.dispatchLocked()
+	fs.mu.Unlock()
+
+	release := func() {
+		fs.mu.Lock()
+		defer fs.mu.Unlock()
+		t.inFlight--
+		t.completed++
+		fs.inFlight--
+		fs.dispatchLocked()
+	}
+
+	select {
+	case <-tk.ready:
+		return release, nil
+	case <-ctx.Done():
+		fs.mu.Lock()
+		defer fs.mu.Unlock()
+		if tk.granted {
+			// El turno llegó junto con la cancelación: ya se contó como
+			// admitida, se devuelve el cupo como completada
+			t.inFlight--
+			t.completed++
+			fs.inFlight--
+			fs.dispatchLocked()
+		} else {
+			for i, w := range t.waiting {
+				if w == tk {
+					t.waiting = append(t.waiting[:i], t.waiting[i+1:]...)
+					break
+				}
+			}
+			t.rejected++
+		}
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+// Concede turnos mientras haya capacidad global disponible
+func (fs *FairScheduler) dispatchLocked() {
+	for fs.capacity <= 0 || fs.inFlight < fs.capacity {
+		t := fs.nextLocked()
+		if t == nil {
+			return
+		}
+		tk := t.waiting[0]
+		t.waiting = t.waiting[1:]
+		tk.granted = true
+		t.inFlight++
+		t.admitted++
+		t.totalWait += time.Since(tk.queued)
+		fs.inFlight++
+		close(tk.ready)
+	}
+}
+
+// Elige el siguiente tenant según deficit round robin (costo 1 por solicitud)
+func (fs *FairScheduler) nextLocked() *tenantQueue {
+	n := len(fs.order)
+	for visited := 0; visited <= n; visited++ {
+		t := fs.tenants[fs.order[fs.next]]
+		if t.eligible() && t.deficit > 0 {
+			t.deficit--
+			return t
+		}
+		if len(t.waiting) == 0 {
+			t.deficit = 0
+		}
+
+		// Pasar al siguiente tenant y acreditarle su quantum
+		fs.next = (fs.next + 1) % n
+		if nt := fs.tenants[fs.order[fs.next]]; nt.eligible() {
+			nt.deficit += nt.config.Weight
+		}
+	}
+	return nil
+}
+
+// Estadísticas de un tenant en un instante
+type TenantStats struct {
+	Name      string        `json:"name"`
+	InFlight  int           `json:"in_flight"`
+	Queued    int           `json:"queued"`
+	Admitted  int64         `json:"admitted"`
+	Completed int64         `json:"completed"`
+	Rejected  int64         `json:"rejected"`
+	AvgWait   time.Duration `json:"avg_wait_ns"`
+}
+
+// Devuelve las estadísticas de todos los tenants ordenadas por nombre
+func (fs *FairScheduler) Stats() []TenantStats {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	stats := make([]TenantStats, 0, len(fs.tenants))
+	for _, t := range fs.tenants {
+		s := TenantStats{
+			Name:      t.name,
+			InFlight:  t.inFlight,
+			Queued:    len(t.waiting),
+			Admitted:  t.admitted,
+			Completed: t.completed,
+			Rejected:  t.rejected,
+		}
+		if t.admitted > 0 {
+			s.AvgWait = t.totalWait / time.Duration(t.admitted)
+		}
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+	return stats
+}
@@ -0,0 +1,144 @@
+package lb
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// Espera hasta que el planificador tenga queued solicitudes en cola
+func waitQueued(t *testing.T, fs *FairScheduler, queued int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		total := 0
+		for _, s := range fs.Stats() {
+			total += s.Queued
+		}
+		if total == queued {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("la cola no llegó a %d solicitudes", queued)
+}
+
+func TestFairSchedulerDRR(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights map[string]int
+		grants  int
+		want    map[string]int
+	}{
+		{"pesos iguales", map[string]int{"a": 1, "b": 1}, 8, map[string]int{"a": 4, "b": 4}},
+		{"tres a uno", map[string]int{"a": 3, "b": 1}, 8, map[string]int{"a": 6, "b": 2}},
+		{"tres tenants", map[string]int{"a": 2, "b": 1, "c": 1}, 8, map[string]int{"a": 4, "b": 2, "c": 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &TenantsFile{Tenants: map[string]TenantConfig{}}
+			for name, w := range tt.weights {
+				cfg.Tenants[name] = TenantConfig{Weight: w}
+			}
+			fs := NewFairScheduler(cfg, 1)
+
+			// Ocupar el único cupo para que todas las demás esperen turno
+			hold, err := fs.Acquire(context.Background(), "a")
+			if err != nil {
+				t.Fatal(err)
+			}
+			type grant struct {
+				tenant  string
+				release func()
+			}
+			grants := make(chan grant)
+			queued := 0
+			for name := range tt.weights {
+				for i := 0; i < tt.grants; i++ {
+					queued++
+					go func(name string) {
+						release, err := fs.Acquire(context.Background(), name)
+						if err != nil {
+							t.Error(err)
+							return
+						}
+						grants <- grant{name, release}
+					}(name)
+				}
+			}
+			waitQueued(t, fs, queued)
+
+			hold()
+			got := make(map[string]int)
+			for i := 0; i < queued; i++ {
+				g := <-grants
+				if i < tt.grants {
+					got[g.tenant]++
+				}
+				g.release()
+			}
+			for name, want := range tt.want {
+				if got[name] != want {
+					t.Errorf("tenant %s: %d turnos en los primeros %d, se esperaban %d (%v)", name, got[name], tt.grants, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestFairSchedulerUnknownTenantsShareDefault(t *testing.T) {
+	cfg := &TenantsFile{
+		Default: TenantConfig{MaxConcurrent: 1, MaxQueue: 2},
+		Tenants: map[string]TenantConfig{"conocido": {}},
+	}
+	fs := NewFairScheduler(cfg, 0)
+
+	release, err := fs.Acquire(context.Background(), "inventado-0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	// Rotar nombres no evade la cola compartida
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for i := 1; i <= 2; i++ {
+		go fs.Acquire(ctx, fmt.Sprintf("inventado-%d", i))
+	}
+	waitQueued(t, fs, 2)
+	if _, err := fs.Acquire(ctx, "inventado-3"); err == nil {
+		t.Fatal("se esperaba la cola por defecto llena")
+	}
+	if _, err := fs.Acquire(context.Background(), "conocido"); err != nil {
+		t.Fatalf("un tenant configurado no debe compartir la cola por defecto: %v", err)
+	}
+
+	names := map[string]bool{}
+	for _, s := range fs.Stats() {
+		names[s.Name] = true
+	}
+	if len(names) != 2 || !names[defaultTenant] || !names["conocido"] {
+		t.Errorf("colas creadas: %v, se esperaban default y conocido", names)
+	}
+}
+
+func TestFairSchedulerCancelCounters(t *testing.T) {
+	fs := NewFairScheduler(nil, 1)
+	release, err := fs.Acquire(context.Background(), "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := fs.Acquire(ctx, "a"); err == nil {
+		t.Fatal("se esperaba un error por el plazo vencido")
+	}
+	release()
+
+	s := fs.Stats()[0]
+	if s.Admitted != 1 || s.Completed != 1 || s.Rejected != 1 || s.InFlight != 0 || s.Queued != 0 {
+		t.Errorf("contadores inesperados: %+v", s)
+	}
+}
@@ -0,0 +1,42 @@
+package lb
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	pb "Distributed_load_balancer/proto"
+	"Distributed_load_balancer/tracelog"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Graba en la traza las solicitudes de trabajo entrantes
+type TraceRecorder struct {
+	writer *tracelog.Writer
+}
+
+func NewTraceRecorder(writer *tracelog.Writer) *TraceRecorder {
+	return &TraceRecorder{writer: writer}
+}
+
+// Interceptor unario que agrega cada ProcessRequest a la traza
+func (tr *TraceRecorder) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if r, ok := req.(*pb.Request); ok {
+		md, _ := metadata.FromIncomingContext(ctx)
+		record := tracelog.Record{
+			Time:     time.Now(),
+			Method:   info.FullMethod[strings.LastIndex(info.FullMethod, "/")+1:],
+			WorkID:   r.WorkId,
+			WorkType: r.WorkType,
+			Tenant:   tenantFromContext(ctx),
+			Metadata: tracelog.FilterMetadata(md),
+		}
+		if err := tr.writer.Write(record); err != nil {
+			log.Printf("Error al grabar la traza: %v", err)
+		}
+	}
+	return handler(ctx, req)
+}
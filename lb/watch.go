@@ -0,0 +1,82 @@
+package lb
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	pb "Distributed_load_balancer/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Suscripción a los reportes de carga de los servidores (WatchLoad)
+type WatchConfig struct {
+	Interval    time.Duration // Reporte al menos cada intervalo aunque la carga no cambie (0 = 1s)
+	MinInterval time.Duration // Separación mínima entre reportes (0 = 10ms)
+	Retry       time.Duration // Espera antes de volver a suscribirse tras un corte (0 = 1s)
+}
+
+// Se suscribe a la carga de los servidores que todavía no tienen suscripción
+func (lb *LoadBalancer) watchServers(servers []string) {
+	if lb.watch == nil {
+		return
+	}
+	lb.watchMu.Lock()
+	defer lb.watchMu.Unlock()
+	for _, server := range servers {
+		if !lb.watching[server] {
+			lb.watching[server] = true
+			go lb.watchLoop(server)
+		}
+	}
+}
+
+// Mantiene la suscripción a la carga de un servidor; si el servidor no
+// implementa WatchLoad se sigue consultando con GetLoad
+func (lb *LoadBalancer) watchLoop(server string) {
+	retry := lb.watch.Retry
+	if retry <= 0 {
+		retry = time.Second
+	}
+	for {
+		err := lb.watchOnce(server)
+		if status.Code(err) == codes.Unimplemented {
+			lb.backends.unwatched(server, nil)
+			log.Printf("Servidor %s no admite la suscripción a la carga; se consultará con GetLoad", server)
+			return
+		}
+		lb.backends.unwatched(server, err)
+		log.Printf("Suscripción a la carga de %s cortada: %v (reintento en %v)", server, err, retry)
+		time.Sleep(retry)
+	}
+}
+
+// Recibe los reportes de carga de un servidor hasta que se corta la suscripción
+func (lb *LoadBalancer) watchOnce(server string) error {
+	conn, err := lb.dial(server)
+	if err != nil {
+		return fmt.Errorf("error al conectar con servidor %s: %v", server, err)
+	}
+	defer conn.Close()
+
+	stream, err := pb.NewLoadBalancerServiceClient(conn).WatchLoad(context.Background(), &pb.WatchLoadRequest{
+		IntervalMs:    lb.watch.Interval.Milliseconds(),
+		MinIntervalMs: lb.watch.MinInterval.Milliseconds(),
+	})
+	if err != nil {
+		return err
+	}
+	for first := true; ; first = false {
+		report, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		if first {
+			log.Printf("Suscripto a la carga de %s", server)
+		}
+		lb.backends.watched(server, report)
+	}
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"Distributed_load_balancer/auth"
+	"Distributed_load_balancer/balancer"
+	"Distributed_load_balancer/faults"
+	"Distributed_load_balancer/lb"
+	pb "Distributed_load_balancer/proto" // Asegúrate de que la ruta del paquete sea correcta
+	"Distributed_load_balancer/tlsutil"
+	"Distributed_load_balancer/tracelog"
+
+	"google.golang.org/grpc"
+)
+
+func main() {
+	serversFile := flag.String("servers", "servers.txt", "archivo con la lista de servidores")
+	strategyName := flag.String("strategy", "least-load", fmt.Sprintf("estrategia de selección de servidor %v", balancer.Names()))
+	port := flag.String("port", ":4000", "puerto del balanceador")
+	tenantsFile := flag.String("tenants", "", "archivo JSON con la configuración de tenants")
+	maxInFlight := flag.Int("max-inflight", 0, "solicitudes simultáneas hacia los servidores (0 = sin límite)")
+	rateLimitsFile := flag.String("ratelimits", "", "archivo JSON con los límites de tasa por peer, tenant y API key")
+	concurrencyLimit := flag.String("concurrency-limit", "", "límite de concurrencia adaptativo: aimd o gradient (vacío = desactivado)")
+	concurrencyScope := flag.String("concurrency-scope", "global", "alcance del límite adaptativo: global o backend")
+	faultsFile := flag.String("faults", "", "archivo JSON con las fallas a inyectar en el balanceador")
+	traceFile := flag.String("trace", "", "archivo JSON Lines donde grabar las solicitudes entrantes (ej. requests.jsonl)")
+	statsInterval := flag.Duration("stats-interval", 30*time.Second, "intervalo para registrar estadísticas")
+	httpAddr := flag.String("http", "localhost:8080", "dirección HTTP del panel web y del estado en JSON (vacío = desactivado)")
+	tlsConfig := &tlsutil.Config{}
+	tlsConfig.RegisterFlags(flag.CommandLine, "tls-", "los clientes")
+	backendTLS := &tlsutil.Config{}
+	backendTLS.RegisterFlags(flag.CommandLine, "backend-tls-", "los servidores")
+	authKeys := flag.String("auth-keys", "", "archivo JSON con las API keys de los clientes (activa la autenticación)")
+	authSecret := flag.String("auth-jwt-secret", "", "archivo con el secreto HMAC para verificar JWT (activa la autenticación)")
+	backendToken := flag.String("backend-token", "", "archivo con el token que el balanceador presenta a los servidores")
+	affinity := flag.Bool("affinity", false, "fijar las sesiones (x-affinity-key o x-session) a un servidor")
+	affinityTTL := flag.Duration("affinity-ttl", 10*time.Minute, "tiempo sin uso tras el que se olvida una sesión")
+	affinityMax := flag.Int("affinity-max", 10000, "sesiones recordadas como máximo")
+	affinitySessions := flag.Bool("affinity-sessions", false, "emitir un token de sesión (x-session) a los clientes que no envían clave")
+	routesFile := flag.String("routes", "", "archivo JSON con los pools y las reglas de enrutamiento (se recarga con SIGHUP)")
+	mirrorLog := flag.String("mirror-log", "mirror.csv", "CSV donde se comparan las respuestas del pool sombra con las del principal")
+	breakersFile := flag.String("breakers", "", "archivo JSON con los umbrales de los circuitos por servidor (vacío = sin circuitos)")
+	slowStart := flag.Duration("slow-start", 0, "ventana de arranque lento de los servidores nuevos o recuperados (0 = desactivado)")
+	slowStartMin := flag.Float64("slow-start-min-weight", 0.1, "peso inicial durante el arranque lento")
+	slowStartAggression := flag.Float64("slow-start-aggression", 1, "curvatura de la rampa de arranque lento (1 = lineal, mayor = más rápida al principio)")
+	var deadlines lb.DeadlineConfig
+	flag.DurationVar(&deadlines.Default, "default-timeout", 0, "plazo de las solicitudes que no traen uno (0 = sin plazo; las rutas pueden cambiarlo)")
+	flag.DurationVar(&deadlines.Max, "max-timeout", 0, "plazo máximo de las solicitudes (0 = sin máximo; las rutas pueden cambiarlo)")
+	flag.DurationVar(&deadlines.Margin, "deadline-margin", 20*time.Millisecond, "margen descontado al plazo que se propaga a los servidores")
+	flag.DurationVar(&deadlines.ProbeTimeout, "probe-timeout", 2*time.Second, "tiempo máximo de una consulta de carga")
+	watchLoad := flag.Bool("watch-load", false, "suscribirse a los reportes de carga de los servidores (WatchLoad) en lugar de consultar GetLoad")
+	watchConfig := &lb.WatchConfig{}
+	flag.DurationVar(&watchConfig.Interval, "watch-interval", time.Second, "intervalo máximo entre reportes de carga de la suscripción")
+	flag.DurationVar(&watchConfig.MinInterval, "watch-min-interval", 10*time.Millisecond, "separación mínima entre reportes de carga de la suscripción")
+	loadReportAge := flag.Duration("load-report-age", 0, "usar la carga que los servidores informan en sus respuestas si tiene menos de esto, sin consultar GetLoad (0 = siempre consultar; con -watch-load, tres intervalos)")
+	flag.Parse()
+
+	// Leer la lista de servidores desde el archivo
+	servers, err := lb.ReadServersFromFile(*serversFile)
+	if err != nil {
+		log.Fatalf("Error al leer las direcciones de los servidores: %v", err)
+	}
+
+	log.Printf("Servidores cargados: %v", servers)
+
+	// Leer la configuración de tenants si se indicó
+	var tenants *lb.TenantsFile
+	if *tenantsFile != "" {
+		tenants, err = lb.ReadTenantsFromFile(*tenantsFile)
+		if err != nil {
+			log.Fatalf("Error al leer la configuración de tenants: %v", err)
+		}
+		log.Printf("Tenants configurados: %d", len(tenants.Tenants))
+	}
+
+	strategy, err := balancer.New(*strategyName, rand.New(rand.NewSource(time.Now().UnixNano())))
+	if err != nil {
+		log.Fatalf("Error al configurar la estrategia: %v", err)
+	}
+
+	var limits *lb.AdaptiveLimits
+	if *concurrencyLimit != "" {
+		limits, err = lb.NewAdaptiveLimits(*concurrencyLimit, *concurrencyScope)
+		if err != nil {
+			log.Fatalf("Error al configurar el límite de concurrencia: %v", err)
+		}
+		log.Printf("Límite de concurrencia %s con alcance %s", *concurrencyLimit, *concurrencyScope)
+	}
+
+	var router *lb.Router
+	if *routesFile != "" {
+		if router, err = lb.NewRouter(*routesFile); err != nil {
+			log.Fatalf("Error al leer las reglas de enrutamiento: %v", err)
+		}
+		log.Printf("Reglas de enrutamiento: %d pools, %d reglas", len(router.Config().Pools), len(router.Config().Routes))
+	}
+
+	var breakers *lb.BreakersFile
+	if *breakersFile != "" {
+		if breakers, err = lb.ReadBreakersFromFile(*breakersFile); err != nil {
+			log.Fatalf("Error al leer la configuración de los circuitos: %v", err)
+		}
+		log.Printf("Circuitos activados (%d servidores con umbrales propios)", len(breakers.Backends))
+	}
+
+	// Crear un servidor GRPC
+	listener, err := net.Listen("tcp", *port)
+	if err != nil {
+		log.Fatalf("Error al iniciar el balanceador de carga: %v", err)
+	}
+
+	// Inyector de fallas sobre las conexiones del balanceador
+	faultListener := faults.NewListener(listener)
+	injector := faults.NewInjector("Balanceador", faultListener)
+	if *faultsFile != "" {
+		cfg, err := faults.LoadFile(*faultsFile)
+		if err != nil {
+			log.Fatalf("Error al leer las fallas: %v", err)
+		}
+		injector.Set(cfg)
+	}
+
+	// Credenciales hacia los servidores
+	backendCreds, err := tlsutil.ClientCredentials(*backendTLS)
+	if err != nil {
+		log.Fatalf("Error en la configuración TLS hacia los servidores: %v", err)
+	}
+
+	dialOptions := []grpc.DialOption{grpc.WithTransportCredentials(backendCreds)}
+	if *backendToken != "" {
+		token, err := os.ReadFile(*backendToken)
+		if err != nil {
+			log.Fatalf("Error al leer el token para los servidores: %v", err)
+		}
+		dialOptions = append(dialOptions, grpc.WithPerRPCCredentials(auth.TokenCredentials{
+			Token:      strings.TrimSpace(string(token)),
+			RequireTLS: backendTLS.Enabled(),
+		}))
+	}
+
+	// Autenticación de los clientes
+	var authenticator *auth.Authenticator
+	if *authKeys != "" || *authSecret != "" {
+		if authenticator, err = auth.NewAuthenticator(*authKeys, *authSecret); err != nil {
+			log.Fatalf("Error al configurar la autenticación: %v", err)
+		}
+		log.Printf("Autenticación de clientes activada")
+	}
+
+	var affinityConfig *lb.AffinityConfig
+	if *affinity {
+		affinityConfig = &lb.AffinityConfig{TTL: *affinityTTL, MaxEntries: *affinityMax, IssueSessions: *affinitySessions}
+		log.Printf("Afinidad de sesión activada (TTL %v, máximo %d sesiones)", *affinityTTL, *affinityMax)
+	}
+
+	if !*watchLoad {
+		watchConfig = nil
+	}
+
+	loadBalancer := lb.New(lb.Config{
+		Servers:       servers,
+		Tenants:       tenants,
+		MaxInFlight:   *maxInFlight,
+		Strategy:      strategy,
+		Limits:        limits,
+		Faults:        injector,
+		DialOptions:   dialOptions,
+		ResponsesFile: "responses.csv",
+		Auth:          authenticator,
+		Affinity:      affinityConfig,
+		Routes:        router,
+		MirrorFile:    *mirrorLog,
+		Breakers:      breakers,
+		Deadlines:     deadlines,
+		LoadReportAge: *loadReportAge,
+		Watch:         watchConfig,
+		SlowStart:     &lb.SlowStartConfig{Window: *slowStart, MinWeight: *slowStartMin, Aggression: *slowStartAggression},
+	})
+
+	// Recargar las reglas de enrutamiento con SIGHUP
+	if router != nil {
+		go func() {
+			hangup := make(chan os.Signal, 1)
+			signal.Notify(hangup, syscall.SIGHUP)
+			for range hangup {
+				if err := loadBalancer.ReloadRoutes(); err != nil {
+					log.Printf("Error al recargar las reglas de enrutamiento: %v", err)
+				}
+			}
+		}()
+	}
+	if *statsInterval > 0 {
+		go loadBalancer.LogStats(*statsInterval)
+	}
+
+	// Panel web con el estado en vivo
+	if *httpAddr != "" {
+		go func() {
+			log.Printf("Panel web en http://%s/", *httpAddr)
+			if err := http.ListenAndServe(*httpAddr, loadBalancer.DashboardHandler()); err != nil {
+				log.Fatalf("Error en el panel web: %v", err)
+			}
+		}()
+	}
+
+	// Interceptores de las solicitudes entrantes
+	var interceptors []grpc.UnaryServerInterceptor
+	// La autenticación fija el tenant antes de grabar la traza y de aplicar
+	// los límites de tasa; las llamadas rechazadas por ella no se graban
+	if authenticator != nil {
+		interceptors = append(interceptors, authenticator.UnaryInterceptor(auth.BalancerPolicy))
+	}
+	if *traceFile != "" {
+		writer, err := tracelog.Create(*traceFile)
+		if err != nil {
+			log.Fatalf("Error al abrir la traza: %v", err)
+		}
+		defer writer.Close()
+		interceptors = append(interceptors, lb.NewTraceRecorder(writer).UnaryInterceptor)
+		log.Printf("Grabando solicitudes en %s", *traceFile)
+	}
+	if *rateLimitsFile != "" {
+		limits, err := lb.ReadRateLimitsFromFile(*rateLimitsFile)
+		if err != nil {
+			log.Fatalf("Error al leer los límites de tasa: %v", err)
+		}
+		interceptors = append(interceptors, lb.NewRateLimiter(limits, tenants).UnaryInterceptor)
+	}
+
+	interceptors = append(interceptors, injector.UnaryInterceptor)
+
+	creds, err := tlsutil.ServerCredentials(*tlsConfig)
+	if err != nil {
+		log.Fatalf("Error en la configuración TLS: %v", err)
+	}
+	options := []grpc.ServerOption{grpc.Creds(creds), grpc.ChainUnaryInterceptor(interceptors...)}
+	if authenticator != nil {
+		options = append(options, grpc.ChainStreamInterceptor(authenticator.StreamInterceptor(auth.BalancerPolicy)))
+	}
+	s := grpc.NewServer(options...)
+	pb.RegisterLoadBalancerServiceServer(s, loadBalancer)
+
+	// Iniciar el servidor
+	log.Printf("Balanceador de carga corriendo en el puerto %s", *port)
+	if err := s.Serve(faultListener); err != nil {
+		log.Fatalf("Error en el balanceador de carga: %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+// Package loadreport lleva la carga de un servidor en la metadata de sus
+// respuestas, para que el balanceador la conozca sin consultar GetLoad.
+package loadreport
+
+import (
+	"strconv"
+
+	pb "Distributed_load_balancer/proto"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// Claves de metadata con la carga del servidor
+const (
+	LoadKey        = "x-load"
+	CapacityKey    = "x-capacity"
+	QueueDepthKey  = "x-queue-depth"
+	UtilizationKey = "x-utilization"
+)
+
+// Metadata con la carga dada
+func ToMetadata(load *pb.LoadResponse) metadata.MD {
+	return metadata.Pairs(
+		LoadKey, strconv.Itoa(int(load.Load)),
+		CapacityKey, strconv.Itoa(int(load.Capacity)),
+		QueueDepthKey, strconv.Itoa(int(load.QueueDepth)),
+		UtilizationKey, strconv.FormatFloat(load.Utilization, 'f', 4, 64),
+	)
+}
+
+// Carga contenida en la metadata; false si no la trae o está mal formada
+func FromMetadata(md metadata.MD) (*pb.LoadResponse, bool) {
+	var ints [3]int32
+	for i, key := range []string{LoadKey, CapacityKey, QueueDepthKey} {
+		values := md.Get(key)
+		if len(values) == 0 {
+			return nil, false
+		}
+		n, err := strconv.ParseInt(values[0], 10, 32)
+		if err != nil {
+			return nil, false
+		}
+		ints[i] = int32(n)
+	}
+	values := md.Get(UtilizationKey)
+	if len(values) == 0 {
+		return nil, false
+	}
+	utilization, err := strconv.ParseFloat(values[0], 64)
+	if err != nil {
+		return nil, false
+	}
+	return &pb.LoadResponse{Load: ints[0], Capacity: ints[1], QueueDepth: ints[2], Utilization: utilization}, true
+}
@@ -0,0 +1,63 @@
+package loadreport
+
+import (
+	"testing"
+
+	pb "Distributed_load_balancer/proto"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestRoundTrip(t *testing.T) {
+	tests := []*pb.LoadResponse{
+		{},
+		{Load: 3, Capacity: 4, QueueDepth: 2, Utilization: 1.25},
+		{Load: 7}, // Sin capacidad
+	}
+	for _, load := range tests {
+		got, ok := FromMetadata(ToMetadata(load))
+		if !ok || !proto.Equal(got, load) {
+			t.Errorf("FromMetadata(ToMetadata(%v)) = %v, %v", load, got, ok)
+		}
+	}
+}
+
+func TestFromMetadataInvalid(t *testing.T) {
+	valid := func() metadata.MD {
+		return ToMetadata(&pb.LoadResponse{Load: 1, Capacity: 2, QueueDepth: 0, Utilization: 0.5})
+	}
+	with := func(key, value string) metadata.MD {
+		md := valid()
+		md.Set(key, value)
+		return md
+	}
+	without := func(key string) metadata.MD {
+		md := valid()
+		md.Delete(key)
+		return md
+	}
+	tests := []struct {
+		name string
+		md   metadata.MD
+	}{
+		{"sin metadata", nil},
+		{"metadata de otra cosa", metadata.Pairs("x-backend", "backend-1")},
+		{"sin x-load", without(LoadKey)},
+		{"sin x-capacity", without(CapacityKey)},
+		{"sin x-queue-depth", without(QueueDepthKey)},
+		{"sin x-utilization", without(UtilizationKey)},
+		{"x-load no numérico", with(LoadKey, "mucha")},
+		{"x-load vacío", with(LoadKey, "")},
+		{"x-load decimal", with(LoadKey, "1.5")},
+		{"x-capacity fuera de rango", with(CapacityKey, "99999999999")},
+		{"x-utilization no numérico", with(UtilizationKey, "alta")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if load, ok := FromMetadata(tt.md); ok {
+				t.Errorf("se aceptó la carga %v", load)
+			}
+		})
+	}
+}
@@ -24,6 +24,8 @@ const _ = grpc.SupportPackageIsVersion7
 type LoadBalancerServiceClient interface {
 	ProcessRequest(ctx context.Context, in *Request, opts ...grpc.CallOption) (*Response, error)
 	GetLoad(ctx context.Context, in *LoadRequest, opts ...grpc.CallOption) (*LoadResponse, error)
+	SetFaults(ctx context.Context, in *FaultConfig, opts ...grpc.CallOption) (*FaultConfig, error)
+	WatchLoad(ctx context.Context, in *WatchLoadRequest, opts ...grpc.CallOption) (LoadBalancerService_WatchLoadClient, error)
 }
 
 type loadBalancerServiceClient struct {
@@ -52,12 +54,55 @@ func (c *loadBalancerServiceClient) GetLoad(ctx context.Context, in *LoadRequest
 	return out, nil
 }
 
+func (c *loadBalancerServiceClient) SetFaults(ctx context.Context, in *FaultConfig, opts ...grpc.CallOption) (*FaultConfig, error) {
+	out := new(FaultConfig)
+	err := c.cc.Invoke(ctx, "/proto.LoadBalancerService/SetFaults", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *loadBalancerServiceClient) WatchLoad(ctx context.Context, in *WatchLoadRequest, opts ...grpc.CallOption) (LoadBalancerService_WatchLoadClient, error) {
+	stream, err := c.cc.NewStream(ctx, &LoadBalancerService_ServiceDesc.Streams[0], "/proto.LoadBalancerService/WatchLoad", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &loadBalancerServiceWatchLoadClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type LoadBalancerService_WatchLoadClient interface {
+	Recv() (*LoadReport, error)
+	grpc.ClientStream
+}
+
+type loadBalancerServiceWatchLoadClient struct {
+	grpc.ClientStream
+}
+
+func (x *loadBalancerServiceWatchLoadClient) Recv() (*LoadReport, error) {
+	m := new(LoadReport)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // LoadBalancerServiceServer is the server API for LoadBalancerService service.
 // All implementations must embed UnimplementedLoadBalancerServiceServer
 // for forward compatibility
 type LoadBalancerServiceServer interface {
 	ProcessRequest(context.Context, *Request) (*Response, error)
 	GetLoad(context.Context, *LoadRequest) (*LoadResponse, error)
+	SetFaults(context.Context, *FaultConfig) (*FaultConfig, error)
+	WatchLoad(*WatchLoadRequest, LoadBalancerService_WatchLoadServer) error
 	mustEmbedUnimplementedLoadBalancerServiceServer()
 }
 
@@ -71,6 +116,12 @@ func (UnimplementedLoadBalancerServiceServer) ProcessRequest(context.Context, *R
 func (UnimplementedLoadBalancerServiceServer) GetLoad(context.Context, *LoadRequest) (*LoadResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetLoad not implemented")
 }
+func (UnimplementedLoadBalancerServiceServer) SetFaults(context.Context, *FaultConfig) (*FaultConfig, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetFaults not implemented")
+}
+func (UnimplementedLoadBalancerServiceServer) WatchLoad(*WatchLoadRequest, LoadBalancerService_WatchLoadServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchLoad not implemented")
+}
 func (UnimplementedLoadBalancerServiceServer) mustEmbedUnimplementedLoadBalancerServiceServer() {}
 
 // UnsafeLoadBalancerServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -120,6 +171,45 @@ func _LoadBalancerService_GetLoad_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _LoadBalancerService_SetFaults_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FaultConfig)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LoadBalancerServiceServer).SetFaults(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.LoadBalancerService/SetFaults",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LoadBalancerServiceServer).SetFaults(ctx, req.(*FaultConfig))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LoadBalancerService_WatchLoad_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchLoadRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LoadBalancerServiceServer).WatchLoad(m, &loadBalancerServiceWatchLoadServer{stream})
+}
+
+type LoadBalancerService_WatchLoadServer interface {
+	Send(*LoadReport) error
+	grpc.ServerStream
+}
+
+type loadBalancerServiceWatchLoadServer struct {
+	grpc.ServerStream
+}
+
+func (x *loadBalancerServiceWatchLoadServer) Send(m *LoadReport) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // LoadBalancerService_ServiceDesc is the grpc.ServiceDesc for LoadBalancerService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -135,7 +225,17 @@ var LoadBalancerService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetLoad",
 			Handler:    _LoadBalancerService_GetLoad_Handler,
 		},
+		{
+			MethodName: "SetFaults",
+			Handler:    _LoadBalancerService_SetFaults_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchLoad",
+			Handler:       _LoadBalancerService_WatchLoad_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "load_balancer.proto",
 }
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Intervalo mínimo entre dos lecturas de /proc/stat para calcular el uso de CPU
+const cpuSampleInterval = 100 * time.Millisecond
+
+// Uso de CPU de la máquina a partir de las diferencias entre lecturas de /proc/stat
+type cpuSampler struct {
+	mu        sync.Mutex
+	busy      uint64
+	total     uint64
+	usage     float64
+	sampledAt time.Time
+}
+
+// Uso de CPU (0-1) desde la lectura anterior; la primera vez, desde el arranque
+// de la máquina. Devuelve 0 si /proc/stat no está disponible.
+func (c *cpuSampler) utilization() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if time.Since(c.sampledAt) < cpuSampleInterval {
+		return c.usage
+	}
+	busy, total, err := readCPUTimes()
+	if err != nil {
+		return 0
+	}
+	if total > c.total {
+		c.usage = float64(busy-c.busy) / float64(total-c.total)
+	}
+	c.busy, c.total, c.sampledAt = busy, total, time.Now()
+	return c.usage
+}
+
+// Tiempos de CPU acumulados de la primera línea de /proc/stat
+func readCPUTimes() (busy, total uint64, err error) {
+	content, err := os.ReadFile("/proc/stat")
+	if err != nil {
+		return 0, 0, err
+	}
+	line, _, _ := strings.Cut(string(content), "\n")
+	fields := strings.Fields(line)
+	if len(fields) < 5 || fields[0] != "cpu" {
+		return 0, 0, fmt.Errorf("formato inesperado de /proc/stat")
+	}
+	// user nice system idle iowait irq softirq steal; guest ya está en user
+	for i, field := range fields[1:] {
+		if i >= 8 {
+			break
+		}
+		n, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("error al interpretar /proc/stat: %v", err)
+		}
+		total += n
+		if i != 3 && i != 4 { // idle e iowait
+			busy += n
+		}
+	}
+	return busy, total, nil
+}
+
+// Valor en bytes de un campo en kB de un archivo de /proc (ej. VmRSS)
+func readProcKB(filename, field string) (uint64, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		name, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok || name != field {
+			continue
+		}
+		kb, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimSpace(value), " kB"), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("error al interpretar %s en %s: %v", field, filename, err)
+		}
+		return kb * 1024, nil
+	}
+	return 0, fmt.Errorf("%s no tiene el campo %s", filename, field)
+}
+
+// Memoria residente del proceso y la fracción que representa de la memoria
+// total; 0 si /proc no está disponible
+func memoryUsage() (rss uint64, utilization float64) {
+	rss, err := readProcKB("/proc/self/status", "VmRSS")
+	if err != nil {
+		return 0, 0
+	}
+	if total, err := readProcKB("/proc/meminfo", "MemTotal"); err == nil && total > 0 {
+		utilization = float64(rss) / float64(total)
+	}
+	return rss, utilization
+}
@@ -0,0 +1,350 @@
+// Package server implementa el servidor de trabajo: procesa las solicitudes
+// reenviadas por el balanceador y reporta su carga.
+package server
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"Distributed_load_balancer/faults"
+	"Distributed_load_balancer/loadreport"
+	pb "Distributed_load_balancer/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Estructura del servidor que implementa el servicio de balanceo de carga
+type Server struct {
+	pb.UnimplementedLoadBalancerServiceServer
+	activeLoads  int32                    // Carga actual del servidor (solicitudes activas)
+	totalHandled int32                    // Total de solicitudes manejadas
+	rejected     int32                    // Solicitudes rechazadas por falta de capacidad
+	queued       int32                    // Solicitudes esperando turno
+	port         string                   // Puerto del servidor
+	capacity     int32                    // Solicitudes simultáneas admitidas (0 = sin límite)
+	queueSize    int32                    // Solicitudes que pueden esperar turno
+	slots        chan struct{}            // Cupos de ejecución (nil si no hay límite)
+	workload     atomic.Pointer[Workload] // Trabajo simulado por solicitud
+	faults       *faults.Injector
+	responses    string // CSV donde se registran las respuestas ("" = no se registran)
+	changes      *loadChanges
+	cpu          cpuSampler
+	metricsMu    sync.Mutex
+	metrics      map[string]float64 // Métricas propias que se envían en los reportes de carga
+}
+
+// Configuración del servidor
+type Config struct {
+	Port          string
+	Capacity      int              // Solicitudes simultáneas admitidas (0 = sin límite)
+	QueueSize     int              // Solicitudes que pueden esperar turno
+	Workload      *Workload        // nil = sin trabajo simulado
+	Faults        *faults.Injector // nil = inyector sin reglas
+	ResponsesFile string
+	Metrics       map[string]float64 // Métricas propias iniciales de los reportes de carga
+}
+
+// Crea un servidor con la configuración dada
+func New(cfg Config) *Server {
+	if cfg.Workload == nil {
+		cfg.Workload = &Workload{Model: "none", Speed: 1}
+	}
+	if cfg.Faults == nil {
+		cfg.Faults = faults.NewInjector("Server "+cfg.Port, nil)
+	}
+	s := &Server{
+		port:      cfg.Port,
+		capacity:  int32(cfg.Capacity),
+		queueSize: int32(cfg.QueueSize),
+		faults:    cfg.Faults,
+		responses: cfg.ResponsesFile,
+		changes:   newLoadChanges(),
+		metrics:   make(map[string]float64),
+	}
+	s.workload.Store(cfg.Workload)
+	for name, value := range cfg.Metrics {
+		s.metrics[name] = value
+	}
+	if cfg.Capacity > 0 {
+		s.slots = make(chan struct{}, cfg.Capacity)
+	}
+	return s
+}
+
+// Inyector de fallas del servidor, para registrar su interceptor en el servidor gRPC
+func (s *Server) Faults() *faults.Injector {
+	return s.faults
+}
+
+// Trabajo simulado actual
+func (s *Server) Workload() *Workload {
+	return s.workload.Load()
+}
+
+// Reemplaza el trabajo simulado; las solicitudes en curso terminan con el anterior
+func (s *Server) SetWorkload(w *Workload) {
+	s.workload.Store(w)
+}
+
+// Total de solicitudes completadas
+func (s *Server) Handled() int {
+	return int(atomic.LoadInt32(&s.totalHandled))
+}
+
+// Obtiene la carga actual con la capacidad, la cola y la utilización
+func (s *Server) currentLoad() *pb.LoadResponse {
+	load := &pb.LoadResponse{
+		Load:       atomic.LoadInt32(&s.activeLoads),
+		Capacity:   s.capacity,
+		QueueDepth: atomic.LoadInt32(&s.queued),
+	}
+	if s.capacity > 0 {
+		load.Utilization = float64(load.Load+load.QueueDepth) / float64(s.capacity)
+	}
+	return load
+}
+
+// Función para manejar la carga del servidor y devolver la carga actual
+func (s *Server) GetLoad(ctx context.Context, req *pb.LoadRequest) (*pb.LoadResponse, error) {
+	load := s.currentLoad()
+	log.Printf("[Server %s] Reportando carga actual: %d (capacidad: %d, en cola: %d, utilización: %.2f)",
+		s.port, load.Load, load.Capacity, load.QueueDepth, load.Utilization)
+	return load, nil
+}
+
+// Adjunta la carga actual a los trailers de la respuesta para que el
+// balanceador la actualice sin consultar GetLoad
+func (s *Server) reportLoad(ctx context.Context) {
+	if err := grpc.SetTrailer(ctx, loadreport.ToMetadata(s.currentLoad())); err != nil {
+		log.Printf("[Server %s] Error al adjuntar la carga a la respuesta: %v", s.port, err)
+	}
+}
+
+// Cambia una métrica propia y avisa a los suscriptos a la carga
+func (s *Server) SetMetric(name string, value float64) {
+	s.metricsMu.Lock()
+	s.metrics[name] = value
+	s.metricsMu.Unlock()
+	s.changes.notify()
+}
+
+// Reporte de carga con el uso de CPU y memoria y las métricas propias
+func (s *Server) loadReport() *pb.LoadReport {
+	report := &pb.LoadReport{
+		Load:           s.currentLoad(),
+		CpuUtilization: s.cpu.utilization(),
+		NamedMetrics: map[string]float64{
+			"handled":  float64(atomic.LoadInt32(&s.totalHandled)),
+			"rejected": float64(atomic.LoadInt32(&s.rejected)),
+		},
+	}
+	report.MemoryBytes, report.MemoryUtilization = memoryUsage()
+	s.metricsMu.Lock()
+	for name, value := range s.metrics {
+		report.NamedMetrics[name] = value
+	}
+	s.metricsMu.Unlock()
+	return report
+}
+
+// Envía un reporte de carga cada vez que la carga cambia (sin superar uno por
+// min_interval_ms) y al menos uno cada interval_ms
+func (s *Server) WatchLoad(req *pb.WatchLoadRequest, stream pb.LoadBalancerService_WatchLoadServer) error {
+	interval := time.Duration(req.IntervalMs) * time.Millisecond
+	if interval <= 0 {
+		interval = time.Second
+	}
+	minInterval := time.Duration(req.MinIntervalMs) * time.Millisecond
+	if minInterval <= 0 {
+		minInterval = 10 * time.Millisecond
+	}
+	ctx := stream.Context()
+	log.Printf("[Server %s] Nueva suscripción a la carga (intervalo %v, mínimo %v)", s.port, interval, minInterval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		changed := s.changes.wait()
+		if err := stream.Send(s.loadReport()); err != nil {
+			log.Printf("[Server %s] Suscripción a la carga terminada: %v", s.port, err)
+			return err
+		}
+		ticker.Reset(interval)
+
+		// Juntar los cambios seguidos en un solo reporte
+		select {
+		case <-ctx.Done():
+			log.Printf("[Server %s] Suscripción a la carga cancelada", s.port)
+			return nil
+		case <-time.After(minInterval):
+		}
+		select {
+		case <-ctx.Done():
+			log.Printf("[Server %s] Suscripción a la carga cancelada", s.port)
+			return nil
+		case <-changed:
+		case <-ticker.C:
+		}
+	}
+}
+
+// Reemplaza en tiempo de ejecución las fallas inyectadas
+func (s *Server) SetFaults(ctx context.Context, cfg *pb.FaultConfig) (*pb.FaultConfig, error) {
+	s.faults.Set(cfg)
+	return s.faults.Config(), nil
+}
+
+// Espera un cupo de ejecución; rechaza si la cola también está llena
+func (s *Server) admit(ctx context.Context) (func(), error) {
+	if s.slots == nil {
+		return func() {}, nil
+	}
+	release := func() { <-s.slots }
+
+	// Cupo libre inmediato
+	select {
+	case s.slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	// Esperar en cola si queda espacio
+	if atomic.AddInt32(&s.queued, 1) > s.queueSize {
+		atomic.AddInt32(&s.queued, -1)
+		atomic.AddInt32(&s.rejected, 1)
+		return nil, status.Errorf(codes.ResourceExhausted, "servidor %s sin capacidad (%d en curso, cola de %d llena)", s.port, s.capacity, s.queueSize)
+	}
+	s.changes.notify()
+	defer func() {
+		atomic.AddInt32(&s.queued, -1)
+		s.changes.notify()
+	}()
+
+	select {
+	case s.slots <- struct{}{}:
+		return release, nil
+	case <-ctx.Done():
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+// Función para procesar solicitudes y guardar la respuesta en un archivo CSV
+func (s *Server) ProcessRequest(ctx context.Context, req *pb.Request) (*pb.Response, error) {
+	// Informar la carga al terminar, también si la solicitud falla
+	defer s.reportLoad(ctx)
+
+	// Esperar un cupo de ejecución
+	release, err := s.admit(ctx)
+	if err != nil {
+		log.Printf("[Server %s] Rechazada solicitud %d: %v", s.port, req.WorkId, err)
+		return nil, err
+	}
+	defer release()
+
+	// No empezar un trabajo cuyo plazo venció mientras esperaba en la cola
+	if err := ctx.Err(); err != nil {
+		log.Printf("[Server %s] Solicitud %d descartada: %v", s.port, req.WorkId, err)
+		return nil, status.FromContextError(err).Err()
+	}
+
+	// Aumentar carga activa
+	atomic.AddInt32(&s.activeLoads, 1)
+	s.changes.notify()
+	defer func() { // Disminuir carga al final
+		atomic.AddInt32(&s.activeLoads, -1)
+		s.changes.notify()
+	}()
+
+	// Simulando procesamiento de la solicitud
+	log.Printf("[Server %s] Procesando solicitud %d", s.port, req.WorkId)
+	if err := s.workload.Load().Run(ctx, req.WorkId); err != nil {
+		log.Printf("[Server %s] Solicitud %d interrumpida: %v", s.port, req.WorkId, err)
+		return nil, status.FromContextError(err).Err()
+	}
+
+	// Simular resultado de la solicitud
+	result := fmt.Sprintf("Resultado de trabajo %d", req.WorkId)
+
+	// Obtener la carga activa actual
+	currentLoad := atomic.LoadInt32(&s.activeLoads)
+
+	// Registrar en CSV
+	if s.responses != "" {
+		go s.saveToCSV(req.WorkId, result, currentLoad)
+	}
+
+	// Incrementar el contador de solicitudes manejadas
+	atomic.AddInt32(&s.totalHandled, 1)
+
+	return &pb.Response{Result: result}, nil
+}
+
+// Función para guardar los resultados en un archivo CSV de manera concurrente
+func (s *Server) saveToCSV(workId int32, result string, load int32) {
+	// Abrir archivo CSV
+	file, err := os.OpenFile(s.responses, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		log.Printf("Error al abrir el archivo CSV: %v", err)
+		return
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	// Si el archivo está vacío, escribir encabezado
+	fileInfo, err := file.Stat()
+	if err != nil {
+		log.Printf("Error al obtener información del archivo CSV: %v", err)
+		return
+	}
+
+	if fileInfo.Size() == 0 {
+		writer.Write([]string{"Timestamp", "TrabajoID", "Resultado", "CargaActiva"})
+	}
+
+	// Escribir en el archivo CSV
+	record := []string{
+		time.Now().Format("2006-01-02 15:04:05"),
+		fmt.Sprintf("%d", workId),
+		result,
+		fmt.Sprintf("%d", load),
+	}
+
+	if err := writer.Write(record); err != nil {
+		log.Printf("Error al escribir en el archivo CSV: %v", err)
+	}
+}
+
+// Aviso de cambios de carga a los suscriptos: cada cambio cierra el canal
+// vigente y crea uno nuevo
+type loadChanges struct {
+	mu sync.Mutex
+	ch chan struct{}
+}
+
+func newLoadChanges() *loadChanges {
+	return &loadChanges{ch: make(chan struct{})}
+}
+
+// Canal que se cierra en el próximo cambio
+func (c *loadChanges) wait() <-chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ch
+}
+
+func (c *loadChanges) notify() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	close(c.ch)
+	c.ch = make(chan struct{})
+}
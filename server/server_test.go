@@ -0,0 +1,303 @@
+package server
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"Distributed_load_balancer/loadreport"
+	pb "Distributed_load_balancer/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+// Levanta el servidor sobre bufconn y devuelve un cliente
+func startServer(t *testing.T, s *Server) pb.LoadBalancerServiceClient {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	g := grpc.NewServer()
+	pb.RegisterLoadBalancerServiceServer(g, s)
+	go g.Serve(listener)
+	t.Cleanup(g.Stop)
+	conn, err := grpc.NewClient("passthrough:///server",
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewLoadBalancerServiceClient(conn)
+}
+
+func TestWatchLoad(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval time.Duration
+		change   func(s *Server, client pb.LoadBalancerServiceClient)
+		within   time.Duration // Plazo para recibir el reporte que cumple want
+		want     func(r *pb.LoadReport) bool
+	}{
+		{"reporte periódico sin cambios", 50 * time.Millisecond, func(*Server, pb.LoadBalancerServiceClient) {}, time.Second,
+			func(r *pb.LoadReport) bool { return r.Load.Load == 0 && r.Load.Capacity == 2 }},
+		{"cambio de carga antes del intervalo", time.Hour, func(s *Server, client pb.LoadBalancerServiceClient) {
+			s.SetWorkload(&Workload{Model: "fixed", Mean: time.Second, Speed: 1})
+			go client.ProcessRequest(context.Background(), &pb.Request{WorkId: 1})
+		}, 500 * time.Millisecond, func(r *pb.LoadReport) bool { return r.Load.Load == 1 && r.Load.Utilization == 0.5 }},
+		{"métrica propia antes del intervalo", time.Hour, func(s *Server, _ pb.LoadBalancerServiceClient) {
+			s.SetMetric("cola_gpu", 3)
+		}, 500 * time.Millisecond, func(r *pb.LoadReport) bool { return r.NamedMetrics["cola_gpu"] == 3 }},
+		{"solicitudes atendidas", time.Hour, func(_ *Server, client pb.LoadBalancerServiceClient) {
+			client.ProcessRequest(context.Background(), &pb.Request{WorkId: 1})
+		}, 500 * time.Millisecond, func(r *pb.LoadReport) bool { return r.NamedMetrics["handled"] == 1 && r.Load.Load == 0 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(Config{Port: "test", Capacity: 2, QueueSize: 2})
+			client := startServer(t, s)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			stream, err := client.WatchLoad(ctx, &pb.WatchLoadRequest{IntervalMs: tt.interval.Milliseconds(), MinIntervalMs: 5})
+			if err != nil {
+				t.Fatal(err)
+			}
+			// El primer reporte llega al suscribirse
+			first, err := stream.Recv()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if first.Load.Load != 0 {
+				t.Errorf("carga inicial %d", first.Load.Load)
+			}
+
+			tt.change(s, client)
+			deadline := time.Now().Add(tt.within)
+			reports := make(chan *pb.LoadReport)
+			go func() {
+				for {
+					r, err := stream.Recv()
+					if err != nil {
+						close(reports)
+						return
+					}
+					select {
+					case reports <- r:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}()
+			for {
+				select {
+				case r, ok := <-reports:
+					if !ok {
+						t.Fatal("la suscripción se cortó")
+					}
+					if tt.want(r) {
+						return
+					}
+				case <-time.After(time.Until(deadline)):
+					t.Fatalf("no llegó el reporte esperado en %v", tt.within)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadTrailer(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity int
+		busy     int // Solicitudes en curso antes de la medida
+		want     *pb.LoadResponse
+		wantCode codes.Code
+	}{
+		{"respuesta exitosa", 2, 0, &pb.LoadResponse{Capacity: 2}, codes.OK},
+		{"respuesta con otra en curso", 2, 1, &pb.LoadResponse{Load: 1, Capacity: 2, Utilization: 0.5}, codes.OK},
+		{"rechazada sin capacidad", 1, 1, &pb.LoadResponse{Load: 1, Capacity: 1, Utilization: 1}, codes.ResourceExhausted},
+		{"sin límite de capacidad", 0, 0, &pb.LoadResponse{}, codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(Config{Port: "test", Capacity: tt.capacity})
+			client := startServer(t, s)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			occupy(t, ctx, s, client, tt.busy)
+
+			var trailer metadata.MD
+			_, err := client.ProcessRequest(context.Background(), &pb.Request{WorkId: 1}, grpc.Trailer(&trailer))
+			if code := status.Code(err); code != tt.wantCode {
+				t.Fatalf("código %v, se esperaba %v", code, tt.wantCode)
+			}
+			load, ok := loadreport.FromMetadata(trailer)
+			if !ok {
+				t.Fatalf("la respuesta no trae la carga: %v", trailer)
+			}
+			if !proto.Equal(load, tt.want) {
+				t.Errorf("carga informada %v, se esperaba %v", load, tt.want)
+			}
+		})
+	}
+}
+
+// Deja n solicitudes en curso hasta que se cancele ctx; las solicitudes
+// siguientes terminan en el acto
+func occupy(t *testing.T, ctx context.Context, s *Server, client pb.LoadBalancerServiceClient, n int) {
+	t.Helper()
+	if n == 0 {
+		return
+	}
+	original := s.Workload()
+	s.SetWorkload(&Workload{Model: "fixed", Mean: time.Hour, Speed: 1})
+	for i := 0; i < n; i++ {
+		go client.ProcessRequest(ctx, &pb.Request{WorkId: int32(100 + i)})
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for s.currentLoad().Load < int32(n) {
+		if time.Now().After(deadline) {
+			t.Fatalf("carga %d, se esperaban %d en curso", s.currentLoad().Load, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	s.SetWorkload(original)
+}
+
+func TestAdmit(t *testing.T) {
+	// Paso de un escenario: admitir una solicitud nueva, cancelar la última
+	// que espera o liberar el cupo de la primera en curso; después se
+	// esperan las cantidades indicadas en curso y en cola
+	type step struct {
+		action   string
+		want     codes.Code // Resultado de "admit" (OK = en curso o en cola)
+		inFlight int
+		queued   int32
+	}
+	tests := []struct {
+		name      string
+		capacity  int
+		queueSize int
+		steps     []step
+	}{
+		{"sin límite", 0, 0, []step{
+			{"admit", codes.OK, 1, 0},
+			{"admit", codes.OK, 2, 0},
+		}},
+		{"encola con los cupos ocupados", 2, 2, []step{
+			{"admit", codes.OK, 1, 0},
+			{"admit", codes.OK, 2, 0},
+			{"admit", codes.OK, 2, 1},
+			{"admit", codes.OK, 2, 2},
+		}},
+		{"cola llena", 1, 1, []step{
+			{"admit", codes.OK, 1, 0},
+			{"admit", codes.OK, 1, 1},
+			{"admit", codes.ResourceExhausted, 1, 1},
+		}},
+		{"sin cola", 1, 0, []step{
+			{"admit", codes.OK, 1, 0},
+			{"admit", codes.ResourceExhausted, 1, 0},
+		}},
+		{"la cancelada libera su lugar", 1, 1, []step{
+			{"admit", codes.OK, 1, 0},
+			{"admit", codes.OK, 1, 1},
+			{"cancel", codes.OK, 1, 0},
+			{"admit", codes.OK, 1, 1},
+		}},
+		{"la primera en cola toma el cupo liberado", 1, 2, []step{
+			{"admit", codes.OK, 1, 0},
+			{"admit", codes.OK, 1, 1},
+			{"admit", codes.OK, 1, 2},
+			{"release", codes.OK, 1, 1},
+			{"release", codes.OK, 1, 0},
+			{"release", codes.OK, 0, 0},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(Config{Port: "test", Capacity: tt.capacity, QueueSize: tt.queueSize})
+			type waiter struct {
+				cancel context.CancelFunc
+				done   chan struct{}
+				err    error
+				free   func()
+			}
+			var running, waiting []*waiter
+			var rejected int32
+			// Espera a que cada solicitud en cola quede en curso o en la cola
+			settle := func(st step) {
+				deadline := time.Now().Add(time.Second)
+				for {
+					for i := 0; i < len(waiting); i++ {
+						w := waiting[i]
+						select {
+						case <-w.done:
+							if w.err != nil {
+								t.Fatalf("la solicitud en cola falló: %v", w.err)
+							}
+							running = append(running, w)
+							waiting = append(waiting[:i], waiting[i+1:]...)
+							i--
+						default:
+						}
+					}
+					if len(running) == st.inFlight && atomic.LoadInt32(&s.queued) == st.queued {
+						return
+					}
+					if time.Now().After(deadline) {
+						t.Fatalf("%d en curso y %d en cola, se esperaban %d y %d", len(running), atomic.LoadInt32(&s.queued), st.inFlight, st.queued)
+					}
+					time.Sleep(time.Millisecond)
+				}
+			}
+			for i, st := range tt.steps {
+				switch st.action {
+				case "admit":
+					ctx, cancel := context.WithCancel(context.Background())
+					defer cancel()
+					w := &waiter{cancel: cancel, done: make(chan struct{})}
+					go func() {
+						defer close(w.done)
+						w.free, w.err = s.admit(ctx)
+					}()
+					if st.want != codes.OK {
+						<-w.done
+						if code := status.Code(w.err); code != st.want {
+							t.Fatalf("paso %d: código %v, se esperaba %v", i+1, code, st.want)
+						}
+						rejected++
+						break
+					}
+					waiting = append(waiting, w)
+				case "cancel":
+					w := waiting[len(waiting)-1]
+					waiting = waiting[:len(waiting)-1]
+					w.cancel()
+					<-w.done
+					if code := status.Code(w.err); code != codes.Canceled {
+						t.Fatalf("paso %d: código %v al cancelar", i+1, code)
+					}
+				case "release":
+					running[0].free()
+					running = running[1:]
+				}
+				settle(st)
+				if got := s.currentLoad().QueueDepth; got != st.queued {
+					t.Errorf("paso %d: QueueDepth %d, se esperaba %d", i+1, got, st.queued)
+				}
+			}
+			if got := atomic.LoadInt32(&s.rejected); got != rejected {
+				t.Errorf("%d rechazadas, se esperaban %d", got, rejected)
+			}
+		})
+	}
+}
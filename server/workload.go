@@ -0,0 +1,137 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Modelo de trabajo simulado por el servidor
+type Workload struct {
+	Model         string        // none, fixed, uniform, exponential o lognormal
+	Mean          time.Duration // Tiempo medio de servicio (fixed, exponential, lognormal)
+	Min           time.Duration // Mínimo para uniform
+	Max           time.Duration // Máximo para uniform
+	Sigma         float64       // Desviación del logaritmo para lognormal
+	CPUIterations int           // Iteraciones de hash base por solicitud
+	MemoryMB      int           // Memoria reservada y recorrida por solicitud
+	Speed         float64       // Factor de velocidad del servidor (2 = el doble de rápido)
+}
+
+// Registra las opciones -work, -work-mean, -work-min, -work-max, -work-sigma,
+// -cpu-iterations, -memory-mb y -speed
+func (w *Workload) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&w.Model, "work", "none", "modelo de tiempo de servicio: none, fixed, uniform, exponential o lognormal")
+	fs.DurationVar(&w.Mean, "work-mean", 100*time.Millisecond, "tiempo medio de servicio (fixed, exponential, lognormal)")
+	fs.DurationVar(&w.Min, "work-min", 50*time.Millisecond, "tiempo mínimo de servicio (uniform)")
+	fs.DurationVar(&w.Max, "work-max", 150*time.Millisecond, "tiempo máximo de servicio (uniform)")
+	fs.Float64Var(&w.Sigma, "work-sigma", 0.5, "desviación del logaritmo del tiempo de servicio (lognormal)")
+	fs.IntVar(&w.CPUIterations, "cpu-iterations", 0, "iteraciones de hash por solicitud, escaladas según el work_id")
+	fs.IntVar(&w.MemoryMB, "memory-mb", 0, "memoria en MB reservada y recorrida por solicitud")
+	fs.Float64Var(&w.Speed, "speed", 1, "factor de velocidad del servidor (2 = el doble de rápido)")
+}
+
+// Comprueba que el modelo sea válido
+func (w *Workload) Validate() error {
+	switch w.Model {
+	case "none":
+	case "fixed", "exponential":
+		if w.Mean < 0 {
+			return fmt.Errorf("el tiempo medio no puede ser negativo: %v", w.Mean)
+		}
+	case "lognormal":
+		if w.Mean <= 0 {
+			return fmt.Errorf("el tiempo medio de lognormal debe ser positivo: %v", w.Mean)
+		}
+		if w.Sigma < 0 {
+			return fmt.Errorf("la desviación no puede ser negativa: %v", w.Sigma)
+		}
+	case "uniform":
+		if w.Min < 0 {
+			return fmt.Errorf("el mínimo no puede ser negativo: %v", w.Min)
+		}
+		if w.Max < w.Min {
+			return fmt.Errorf("el máximo (%v) no puede ser menor que el mínimo (%v)", w.Max, w.Min)
+		}
+	default:
+		return fmt.Errorf("modelo de trabajo desconocido: %s", w.Model)
+	}
+	if w.CPUIterations < 0 || w.MemoryMB < 0 {
+		return fmt.Errorf("las iteraciones de CPU (%d) y la memoria (%d MB) no pueden ser negativas", w.CPUIterations, w.MemoryMB)
+	}
+	if w.Speed <= 0 {
+		return fmt.Errorf("el factor de velocidad debe ser positivo: %v", w.Speed)
+	}
+	return nil
+}
+
+// Muestrea un tiempo de servicio según el modelo, ajustado por la velocidad
+func (w *Workload) serviceTime() time.Duration {
+	var d float64
+	switch w.Model {
+	case "fixed":
+		d = float64(w.Mean)
+	case "uniform":
+		d = float64(w.Min) + rand.Float64()*float64(w.Max-w.Min)
+	case "exponential":
+		d = rand.ExpFloat64() * float64(w.Mean)
+	case "lognormal":
+		// mu se elige para que la media de la distribución sea Mean
+		mu := math.Log(float64(w.Mean)) - w.Sigma*w.Sigma/2
+		d = math.Exp(mu + w.Sigma*rand.NormFloat64())
+	}
+	return time.Duration(d / w.Speed)
+}
+
+// Iteraciones de hash para un trabajo: entre 0.5x y 1.5x la base según el work_id
+func (w *Workload) iterations(workID int32) int {
+	if w.CPUIterations <= 0 {
+		return 0
+	}
+	sum := sha256.Sum256(binary.BigEndian.AppendUint32(nil, uint32(workID)))
+	factor := 0.5 + float64(binary.BigEndian.Uint16(sum[:2]))/math.MaxUint16
+	return int(float64(w.CPUIterations) * factor / w.Speed)
+}
+
+// Ejecuta el trabajo simulado; se interrumpe si se cancela el contexto
+func (w *Workload) Run(ctx context.Context, workID int32) error {
+	// Trabajo de memoria: reservar y recorrer cada página
+	if w.MemoryMB > 0 {
+		buf := make([]byte, w.MemoryMB<<20)
+		for i := 0; i < len(buf); i += 4096 {
+			if i%(1<<20) == 0 && ctx.Err() != nil {
+				return ctx.Err()
+			}
+			buf[i] = byte(i)
+		}
+	}
+
+	// Trabajo de CPU: cadena de hashes partiendo del work_id
+	if n := w.iterations(workID); n > 0 {
+		sum := sha256.Sum256(binary.BigEndian.AppendUint32(nil, uint32(workID)))
+		for i := 0; i < n; i++ {
+			if i%1024 == 0 && ctx.Err() != nil {
+				return ctx.Err()
+			}
+			sum = sha256.Sum256(sum[:])
+		}
+	}
+
+	// Tiempo de servicio
+	if w.Model == "none" {
+		return nil
+	}
+	timer := time.NewTimer(w.serviceTime())
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,183 @@
+package server
+
+import (
+	"context"
+	"flag"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestWorkloadFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    Workload
+		wantErr bool // Error de Validate
+	}{
+		{"por defecto", nil, Workload{Model: "none", Mean: 100 * time.Millisecond, Min: 50 * time.Millisecond, Max: 150 * time.Millisecond, Sigma: 0.5, Speed: 1}, false},
+		{"uniforme", []string{"-work=uniform", "-work-min=10ms", "-work-max=20ms", "-speed=2"},
+			Workload{Model: "uniform", Mean: 100 * time.Millisecond, Min: 10 * time.Millisecond, Max: 20 * time.Millisecond, Sigma: 0.5, Speed: 2}, false},
+		{"CPU y memoria", []string{"-work=fixed", "-work-mean=5ms", "-cpu-iterations=1000", "-memory-mb=4"},
+			Workload{Model: "fixed", Mean: 5 * time.Millisecond, Min: 50 * time.Millisecond, Max: 150 * time.Millisecond, Sigma: 0.5, CPUIterations: 1000, MemoryMB: 4, Speed: 1}, false},
+		{"uniforme invertido", []string{"-work=uniform", "-work-min=20ms", "-work-max=10ms"},
+			Workload{Model: "uniform", Mean: 100 * time.Millisecond, Min: 20 * time.Millisecond, Max: 10 * time.Millisecond, Sigma: 0.5, Speed: 1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("server", flag.ContinueOnError)
+			var w Workload
+			w.RegisterFlags(fs)
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			if w != tt.want {
+				t.Errorf("%+v, se esperaba %+v", w, tt.want)
+			}
+			if err := w.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, se esperaba error: %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Valores que no son del tipo de la opción
+	for _, args := range [][]string{{"-work-mean=rápido"}, {"-speed=x"}, {"-cpu-iterations=1.5"}} {
+		fs := flag.NewFlagSet("server", flag.ContinueOnError)
+		fs.SetOutput(nopWriter{})
+		new(Workload).RegisterFlags(fs)
+		if err := fs.Parse(args); err == nil {
+			t.Errorf("se aceptó %v", args)
+		}
+	}
+}
+
+type nopWriter struct{}
+
+func (nopWriter) Write(p []byte) (int, error) { return len(p), nil }
+
+func TestWorkloadValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		w       Workload
+		wantErr bool
+	}{
+		{"none", Workload{Model: "none", Speed: 1}, false},
+		{"fixed", Workload{Model: "fixed", Mean: time.Millisecond, Speed: 1}, false},
+		{"uniforme de ancho cero", Workload{Model: "uniform", Min: time.Millisecond, Max: time.Millisecond, Speed: 1}, false},
+		{"lognormal", Workload{Model: "lognormal", Mean: time.Millisecond, Sigma: 0.5, Speed: 1}, false},
+		{"modelo desconocido", Workload{Model: "pareto", Speed: 1}, true},
+		{"modelo vacío", Workload{Speed: 1}, true},
+		{"uniforme invertido", Workload{Model: "uniform", Min: 2 * time.Millisecond, Max: time.Millisecond, Speed: 1}, true},
+		{"uniforme negativo", Workload{Model: "uniform", Min: -time.Millisecond, Max: time.Millisecond, Speed: 1}, true},
+		{"media negativa", Workload{Model: "exponential", Mean: -time.Millisecond, Speed: 1}, true},
+		{"lognormal sin media", Workload{Model: "lognormal", Sigma: 0.5, Speed: 1}, true},
+		{"lognormal con desviación negativa", Workload{Model: "lognormal", Mean: time.Millisecond, Sigma: -1, Speed: 1}, true},
+		{"iteraciones negativas", Workload{Model: "none", CPUIterations: -1, Speed: 1}, true},
+		{"memoria negativa", Workload{Model: "none", MemoryMB: -1, Speed: 1}, true},
+		{"sin velocidad", Workload{Model: "none"}, true},
+		{"velocidad negativa", Workload{Model: "none", Speed: -1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.w.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, se esperaba error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestServiceTimeScaling(t *testing.T) {
+	const samples = 20000
+	tests := []struct {
+		name     string
+		w        Workload
+		wantMean time.Duration
+		min, max time.Duration // Rango de cada muestra
+		exact    bool          // Todas las muestras valen wantMean
+	}{
+		{"none", Workload{Model: "none", Mean: time.Second, Speed: 1}, 0, 0, 0, true},
+		{"fixed", Workload{Model: "fixed", Mean: 10 * time.Millisecond, Speed: 1}, 10 * time.Millisecond, 0, 0, true},
+		{"fixed al doble de velocidad", Workload{Model: "fixed", Mean: 10 * time.Millisecond, Speed: 2}, 5 * time.Millisecond, 0, 0, true},
+		{"uniforme", Workload{Model: "uniform", Min: 10 * time.Millisecond, Max: 30 * time.Millisecond, Speed: 1}, 20 * time.Millisecond, 10 * time.Millisecond, 30 * time.Millisecond, false},
+		{"uniforme a la mitad de velocidad", Workload{Model: "uniform", Min: 10 * time.Millisecond, Max: 30 * time.Millisecond, Speed: 0.5}, 40 * time.Millisecond, 20 * time.Millisecond, 60 * time.Millisecond, false},
+		{"exponencial", Workload{Model: "exponential", Mean: 10 * time.Millisecond, Speed: 1}, 10 * time.Millisecond, 0, time.Hour, false},
+		{"exponencial al doble de velocidad", Workload{Model: "exponential", Mean: 10 * time.Millisecond, Speed: 2}, 5 * time.Millisecond, 0, time.Hour, false},
+		{"lognormal", Workload{Model: "lognormal", Mean: 10 * time.Millisecond, Sigma: 0.5, Speed: 1}, 10 * time.Millisecond, 0, time.Hour, false},
+		{"lognormal al cuádruple de velocidad", Workload{Model: "lognormal", Mean: 10 * time.Millisecond, Sigma: 0.5, Speed: 4}, 2500 * time.Microsecond, 0, time.Hour, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sum float64
+			for i := 0; i < samples; i++ {
+				d := tt.w.serviceTime()
+				if tt.exact && d != tt.wantMean {
+					t.Fatalf("muestra %v, se esperaba %v", d, tt.wantMean)
+				}
+				if !tt.exact && (d < tt.min || d > tt.max) {
+					t.Fatalf("muestra %v fuera de [%v, %v]", d, tt.min, tt.max)
+				}
+				sum += float64(d)
+			}
+			// Con 20000 muestras la media queda a menos del 1% salvo mala suerte
+			mean := sum / samples
+			if diff := math.Abs(mean-float64(tt.wantMean)) / math.Max(float64(tt.wantMean), 1); diff > 0.05 {
+				t.Errorf("media %v, se esperaba %v", time.Duration(mean), tt.wantMean)
+			}
+		})
+	}
+}
+
+func TestIterationsScaling(t *testing.T) {
+	base := Workload{Model: "none", CPUIterations: 1000, Speed: 1}
+	double := base
+	double.Speed = 2
+	for id := int32(0); id < 200; id++ {
+		n := base.iterations(id)
+		if n < 500 || n > 1500 {
+			t.Fatalf("trabajo %d: %d iteraciones, fuera de [500, 1500]", id, n)
+		}
+		if again := base.iterations(id); again != n {
+			t.Fatalf("trabajo %d: %d y %d iteraciones para el mismo work_id", id, n, again)
+		}
+		if fast := double.iterations(id); fast < n/2-1 || fast > n/2+1 {
+			t.Fatalf("trabajo %d: %d iteraciones al doble de velocidad, se esperaban %d", id, fast, n/2)
+		}
+	}
+	if n := (&Workload{Model: "none", Speed: 1}).iterations(1); n != 0 {
+		t.Errorf("%d iteraciones sin trabajo de CPU", n)
+	}
+}
+
+func TestWorkloadRun(t *testing.T) {
+	tests := []struct {
+		name      string
+		w         Workload
+		cancelled bool
+		wantErr   bool
+		atLeast   time.Duration
+	}{
+		{"tiempo de servicio", Workload{Model: "fixed", Mean: 20 * time.Millisecond, Speed: 1}, false, false, 20 * time.Millisecond},
+		{"CPU", Workload{Model: "none", CPUIterations: 10000, Speed: 1}, false, false, 0},
+		{"memoria", Workload{Model: "none", MemoryMB: 2, Speed: 1}, false, false, 0},
+		{"cancelado durante el tiempo de servicio", Workload{Model: "fixed", Mean: time.Hour, Speed: 1}, true, true, 0},
+		{"cancelado durante el trabajo de CPU", Workload{Model: "none", CPUIterations: 1 << 30, Speed: 1}, true, true, 0},
+		{"cancelado durante el trabajo de memoria", Workload{Model: "none", MemoryMB: 8, Speed: 1}, true, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			if tt.cancelled {
+				cancel()
+			}
+			defer cancel()
+			start := time.Now()
+			err := tt.w.Run(ctx, 1)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Run() = %v, se esperaba error: %v", err, tt.wantErr)
+			}
+			if elapsed := time.Since(start); elapsed < tt.atLeast || (tt.cancelled && elapsed > time.Second) {
+				t.Errorf("Run tardó %v", elapsed)
+			}
+		})
+	}
+}
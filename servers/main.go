@@ -0,0 +1,131 @@
+// Servidor de trabajo: escucha en el puerto dado y procesa las solicitudes
+// que le reenvía el balanceador.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+
+	"Distributed_load_balancer/auth"
+	"Distributed_load_balancer/faults"
+	pb "Distributed_load_balancer/proto"
+	"Distributed_load_balancer/server"
+	"Distributed_load_balancer/tlsutil"
+
+	"google.golang.org/grpc"
+)
+
+func main() {
+	capacity := flag.Int("capacity", 0, "solicitudes simultáneas admitidas (0 = sin límite)")
+	queueSize := flag.Int("queue", 0, "solicitudes que pueden esperar turno cuando se alcanza la capacidad")
+	workload := &server.Workload{}
+	workload.RegisterFlags(flag.CommandLine)
+	faultsFile := flag.String("faults", "", "archivo JSON con las fallas a inyectar")
+	tlsConfig := &tlsutil.Config{}
+	tlsConfig.RegisterFlags(flag.CommandLine, "tls-", "los clientes (ej. el balanceador)")
+	authKeys := flag.String("auth-keys", "", "archivo JSON con las API keys aceptadas (activa la autenticación)")
+	authSecret := flag.String("auth-jwt-secret", "", "archivo con el secreto HMAC para verificar JWT (activa la autenticación)")
+	metricsFlag := flag.String("metrics", "", "métricas propias de los reportes de carga (ej. gpu_mem=0.5,costo=2)")
+	flag.Parse()
+
+	metrics, err := parseMetrics(*metricsFlag)
+	if err != nil {
+		log.Fatalf("Métricas inválidas: %v", err)
+	}
+
+	if err := workload.Validate(); err != nil {
+		log.Fatalf("Modelo de trabajo inválido: %v", err)
+	}
+
+	// Verificar argumentos
+	if flag.NArg() < 1 {
+		log.Fatal("Debes proporcionar un puerto (ejemplo: ./server [-capacity 8 -queue 16] :50051)")
+	}
+	port := flag.Arg(0)
+
+	// Asegurar que el puerto comience con ':'
+	if port[0] != ':' {
+		port = ":" + port
+	}
+
+	// Iniciar el servidor gRPC
+	listener, err := net.Listen("tcp", port)
+	if err != nil {
+		log.Fatalf("Error al iniciar el servidor en puerto %s: %v", port, err)
+	}
+
+	// Inyector de fallas sobre las conexiones del servidor
+	faultListener := faults.NewListener(listener)
+	injector := faults.NewInjector("Server "+port, faultListener)
+	if *faultsFile != "" {
+		cfg, err := faults.LoadFile(*faultsFile)
+		if err != nil {
+			log.Fatalf("Error al leer las fallas: %v", err)
+		}
+		injector.Set(cfg)
+	}
+
+	// Crear e inicializar el servidor
+	srv := server.New(server.Config{
+		Port:          port,
+		Capacity:      *capacity,
+		QueueSize:     *queueSize,
+		Workload:      workload,
+		Faults:        injector,
+		ResponsesFile: "responses.csv",
+		Metrics:       metrics,
+	})
+
+	// Crear un servidor gRPC
+	creds, err := tlsutil.ServerCredentials(*tlsConfig)
+	if err != nil {
+		log.Fatalf("Error en la configuración TLS: %v", err)
+	}
+	// Solo el balanceador (rol balancer) puede enviar trabajos si hay autenticación
+	options := []grpc.ServerOption{grpc.Creds(creds)}
+	interceptors := []grpc.UnaryServerInterceptor{injector.UnaryInterceptor}
+	if *authKeys != "" || *authSecret != "" {
+		authenticator, err := auth.NewAuthenticator(*authKeys, *authSecret)
+		if err != nil {
+			log.Fatalf("Error al configurar la autenticación: %v", err)
+		}
+		interceptors = append([]grpc.UnaryServerInterceptor{authenticator.UnaryInterceptor(auth.ServerPolicy)}, interceptors...)
+		options = append(options, grpc.ChainStreamInterceptor(authenticator.StreamInterceptor(auth.ServerPolicy)))
+	}
+	options = append(options, grpc.ChainUnaryInterceptor(interceptors...))
+	s := grpc.NewServer(options...)
+	pb.RegisterLoadBalancerServiceServer(s, srv)
+
+	// Log de inicio del servidor
+	log.Printf("Servidor iniciado en puerto %s (Carga inicial: 0, capacidad: %d, cola: %d, trabajo: %s, velocidad: %.2f)",
+		port, *capacity, *queueSize, workload.Model, workload.Speed)
+
+	// Iniciar el servidor
+	if err := s.Serve(faultListener); err != nil {
+		log.Fatalf("Error en el servidor %s: %v", port, err)
+	}
+}
+
+// Interpreta una lista nombre=valor separada por comas
+func parseMetrics(list string) (map[string]float64, error) {
+	metrics := make(map[string]float64)
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(item, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("se esperaba nombre=valor en %q", item)
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("valor inválido para %s: %v", name, err)
+		}
+		metrics[name] = v
+	}
+	return metrics, nil
+}
@@ -6,14 +6,22 @@ read num_servers
 start_port=50051
 servers_file="servers.txt"  # Ruta al archivo en el directorio raíz
 
+# Opcional: SERVER_FLAGS con flags comunes (ej. "-work exponential -work-mean 200ms")
+# y SPEEDS con factores de velocidad que se asignan en ciclo (ej. "1 1 0.5 2")
+speeds=($SPEEDS)
+
 > "$servers_file"  # Limpiar el archivo si ya existe
 
 for ((i=0; i<num_servers; i++))
 do
     port=$((start_port + i))
-    go run ./servers/server.go ":$port" &
+    flags="$SERVER_FLAGS"
+    if [ ${#speeds[@]} -gt 0 ]; then
+        flags="$flags -speed ${speeds[$((i % ${#speeds[@]}))]}"
+    fi
+    go run ./servers $flags ":$port" &
     echo "localhost:$port" >> "$servers_file"  # Guardar la dirección del servidor
-    echo "Servidor iniciado en el puerto $port"
+    echo "Servidor iniciado en el puerto $port $flags"
 done
 
 echo "Todos los servidores han sido iniciados."
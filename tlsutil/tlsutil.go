@@ -0,0 +1,268 @@
+// Package tlsutil arma las credenciales TLS y mTLS de gRPC para el cliente,
+// el balanceador y los servidores. Los certificados se vuelven a leer cuando
+// cambian los archivos, sin reiniciar el proceso.
+package tlsutil
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/peer"
+)
+
+// Cada cuánto se revisa si cambiaron los archivos como máximo
+const reloadCheckInterval = time.Second
+
+// Configuración TLS de un extremo de la conexión
+type Config struct {
+	CertFile   string   // Certificado propio (obligatorio en el servidor, opcional en el cliente para mTLS)
+	KeyFile    string   // Clave privada del certificado propio
+	CAFile     string   // CA con la que se verifica al otro extremo; en el servidor activa mTLS
+	Names      []string // Identidades aceptadas del otro extremo (vacío = cualquiera firmada por la CA)
+	ServerName string   // Cliente: nombre esperado en el certificado del servidor (vacío = el de la dirección)
+}
+
+// Indica si se configuró TLS
+func (c Config) Enabled() bool {
+	return c.CertFile != "" || c.CAFile != ""
+}
+
+// Convierte una lista separada por comas en identidades
+func ParseNames(list string) []string {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Archivos leídos y la última fecha de modificación de cada uno
+type reloader struct {
+	cfg Config
+
+	mu       sync.Mutex
+	checked  time.Time
+	modTimes map[string]time.Time
+	cert     *tls.Certificate
+	pool     *x509.CertPool
+}
+
+func newReloader(cfg Config) (*reloader, error) {
+	r := &reloader{cfg: cfg, modTimes: make(map[string]time.Time)}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Lee el certificado y la CA
+func (r *reloader) load() error {
+	var cert *tls.Certificate
+	if r.cfg.CertFile != "" {
+		c, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+		if err != nil {
+			return fmt.Errorf("error al leer el certificado %s: %v", r.cfg.CertFile, err)
+		}
+		cert = &c
+	}
+	var pool *x509.CertPool
+	if r.cfg.CAFile != "" {
+		pem, err := os.ReadFile(r.cfg.CAFile)
+		if err != nil {
+			return fmt.Errorf("error al leer la CA %s: %v", r.cfg.CAFile, err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("la CA %s no contiene certificados válidos", r.cfg.CAFile)
+		}
+	}
+	r.cert, r.pool = cert, pool
+	for _, file := range []string{r.cfg.CertFile, r.cfg.KeyFile, r.cfg.CAFile} {
+		if file == "" {
+			continue
+		}
+		if info, err := os.Stat(file); err == nil {
+			r.modTimes[file] = info.ModTime()
+		}
+	}
+	return nil
+}
+
+// Certificado y CA vigentes; los vuelve a leer si cambió algún archivo.
+// Si la nueva versión es inválida se sigue usando la anterior.
+func (r *reloader) current() (*tls.Certificate, *x509.CertPool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if time.Since(r.checked) < reloadCheckInterval {
+		return r.cert, r.pool
+	}
+	r.checked = time.Now()
+
+	changed := false
+	for file, modTime := range r.modTimes {
+		if info, err := os.Stat(file); err == nil && !info.ModTime().Equal(modTime) {
+			changed = true
+		}
+	}
+	if changed {
+		if err := r.load(); err != nil {
+			log.Printf("[TLS] Error al recargar los certificados, se mantienen los anteriores: %v", err)
+		} else {
+			log.Printf("[TLS] Certificados recargados (%s)", strings.Join(r.files(), ", "))
+		}
+	}
+	return r.cert, r.pool
+}
+
+func (r *reloader) files() []string {
+	var files []string
+	for _, file := range []string{r.cfg.CertFile, r.cfg.CAFile} {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// Identidades de un certificado: el CN y los nombres alternativos
+func Identities(cert *x509.Certificate) []string {
+	ids := []string{}
+	if cert.Subject.CommonName != "" {
+		ids = append(ids, cert.Subject.CommonName)
+	}
+	ids = append(ids, cert.DNSNames...)
+	for _, uri := range cert.URIs {
+		ids = append(ids, uri.String())
+	}
+	return ids
+}
+
+// Verifica que el certificado tenga alguna de las identidades aceptadas
+func checkNames(cert *x509.Certificate, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	for _, id := range Identities(cert) {
+		for _, name := range names {
+			if id == name {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("identidad %v no autorizada", Identities(cert))
+}
+
+// Credenciales del lado servidor; con CAFile exige certificado de cliente (mTLS)
+func ServerCredentials(cfg Config) (credentials.TransportCredentials, error) {
+	if !cfg.Enabled() {
+		return insecure.NewCredentials(), nil
+	}
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, fmt.Errorf("el servidor TLS necesita certificado y clave")
+	}
+	r, err := newReloader(cfg)
+	if err != nil {
+		return nil, err
+	}
+	base := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cert, pool := r.current()
+			c := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*cert},
+				NextProtos:   []string{"h2"},
+			}
+			if pool != nil {
+				c.ClientCAs = pool
+				c.ClientAuth = tls.RequireAndVerifyClientCert
+				c.VerifyConnection = func(cs tls.ConnectionState) error {
+					return checkNames(cs.PeerCertificates[0], cfg.Names)
+				}
+			}
+			return c, nil
+		},
+	}
+	return credentials.NewTLS(base), nil
+}
+
+// Credenciales del lado cliente; con CertFile presenta certificado (mTLS)
+func ClientCredentials(cfg Config) (credentials.TransportCredentials, error) {
+	if !cfg.Enabled() {
+		return insecure.NewCredentials(), nil
+	}
+	r, err := newReloader(cfg)
+	if err != nil {
+		return nil, err
+	}
+	c := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: cfg.ServerName,
+		// La verificación se hace en VerifyConnection con la CA vigente,
+		// porque RootCAs no se puede cambiar una vez creadas las credenciales
+		InsecureSkipVerify: true,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			_, pool := r.current()
+			if len(cs.PeerCertificates) == 0 {
+				return fmt.Errorf("el servidor no presentó certificado")
+			}
+			intermediates := x509.NewCertPool()
+			for _, cert := range cs.PeerCertificates[1:] {
+				intermediates.AddCert(cert)
+			}
+			leaf := cs.PeerCertificates[0]
+			if _, err := leaf.Verify(x509.VerifyOptions{
+				Roots:         pool, // nil = CAs del sistema
+				DNSName:       cs.ServerName,
+				Intermediates: intermediates,
+			}); err != nil {
+				return err
+			}
+			return checkNames(leaf, cfg.Names)
+		},
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			if cert, _ := r.current(); cert != nil {
+				return cert, nil
+			}
+			return &tls.Certificate{}, nil
+		},
+	}
+	return credentials.NewTLS(c), nil
+}
+
+// Identidad verificada del cliente de la llamada ("" si no presentó certificado)
+func PeerIdentity(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	return info.State.VerifiedChains[0][0].Subject.CommonName
+}
+
+// Registra las opciones -<prefix>cert, -<prefix>key, -<prefix>ca y -<prefix>names;
+// peer describe al otro extremo de la conexión en la ayuda
+func (c *Config) RegisterFlags(fs *flag.FlagSet, prefix, peer string) {
+	fs.StringVar(&c.CertFile, prefix+"cert", "", "certificado TLS propio en PEM")
+	fs.StringVar(&c.KeyFile, prefix+"key", "", "clave privada del certificado TLS propio")
+	fs.StringVar(&c.CAFile, prefix+"ca", "", "CA en PEM para verificar a "+peer+" (en el lado servidor exige certificado de cliente)")
+	fs.Func(prefix+"names", "identidades aceptadas de "+peer+" (CN o SAN) separadas por comas", func(list string) error {
+		c.Names = ParseNames(list)
+		return nil
+	})
+}
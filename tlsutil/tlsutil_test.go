@@ -0,0 +1,223 @@
+package tlsutil
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Certificado de prueba con su clave
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// Firma un certificado (issuer nil = CA autofirmada) y lo guarda en dir
+// como <name>.pem y <name>-key.pem
+func writeCert(t *testing.T, dir, name string, issuer *testCert, hosts ...string) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, h)
+		}
+	}
+	parent, signer := template, key
+	if issuer == nil {
+		template.IsCA, template.BasicConstraintsValid = true, true
+		template.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		parent, signer = issuer.cert, issuer.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	write := func(file, blockType string, der []byte) {
+		if err := os.WriteFile(filepath.Join(dir, file), pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(name+".pem", "CERTIFICATE", der)
+	write(name+"-key.pem", "EC PRIVATE KEY", keyDER)
+	return &testCert{cert: cert, key: key}
+}
+
+func TestCredentials(t *testing.T) {
+	dir := t.TempDir()
+	ca := writeCert(t, dir, "ca", nil)
+	writeCert(t, dir, "otra-ca", nil)
+	writeCert(t, dir, "servidor", ca, "localhost", "127.0.0.1")
+	writeCert(t, dir, "cliente", ca)
+	path := func(name string) string { return filepath.Join(dir, name) }
+
+	tlsServer := Config{CertFile: path("servidor.pem"), KeyFile: path("servidor-key.pem")}
+	mtlsServer := tlsServer
+	mtlsServer.CAFile = path("ca.pem")
+	tlsClient := Config{CAFile: path("ca.pem")}
+	mtlsClient := Config{CAFile: path("ca.pem"), CertFile: path("cliente.pem"), KeyFile: path("cliente-key.pem")}
+
+	with := func(cfg Config, change func(*Config)) Config {
+		change(&cfg)
+		return cfg
+	}
+	tests := []struct {
+		name     string
+		server   Config
+		client   Config
+		wantErr  bool
+		identity string // Identidad del cliente vista por el servidor
+	}{
+		{"sin TLS", Config{}, Config{}, false, ""},
+		{"TLS", tlsServer, tlsClient, false, ""},
+		{"mTLS", mtlsServer, mtlsClient, false, "cliente"},
+		{"mTLS sin certificado de cliente", mtlsServer, tlsClient, true, ""},
+		{"CA desconocida", tlsServer, Config{CAFile: path("otra-ca.pem")}, true, ""},
+		{"identidad del servidor aceptada", tlsServer, with(tlsClient, func(c *Config) { c.Names = []string{"servidor"} }), false, ""},
+		{"identidad del servidor rechazada", tlsServer, with(tlsClient, func(c *Config) { c.Names = []string{"balanceador"} }), true, ""},
+		{"identidad del cliente rechazada", with(mtlsServer, func(c *Config) { c.Names = []string{"admin"} }), mtlsClient, true, ""},
+		{"cliente TLS contra servidor sin TLS", Config{}, tlsClient, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serverCreds, err := ServerCredentials(tt.server)
+			if err != nil {
+				t.Fatal(err)
+			}
+			identity := make(chan string, 1)
+			s := grpc.NewServer(grpc.Creds(serverCreds), grpc.UnaryInterceptor(
+				func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+					identity <- PeerIdentity(ctx)
+					return handler(ctx, req)
+				}))
+			healthpb.RegisterHealthServer(s, health.NewServer())
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			go s.Serve(listener)
+			defer s.Stop()
+
+			clientCreds, err := ClientCredentials(tt.client)
+			if err != nil {
+				t.Fatal(err)
+			}
+			conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(clientCreds))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			_, err = healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, se esperaba error: %v", err, tt.wantErr)
+			}
+			if err == nil {
+				if got := <-identity; got != tt.identity {
+					t.Errorf("identidad %q, se esperaba %q", got, tt.identity)
+				}
+			}
+		})
+	}
+}
+
+func TestReloadKeepsPreviousOnError(t *testing.T) {
+	dir := t.TempDir()
+	ca := writeCert(t, dir, "ca", nil)
+	first := writeCert(t, dir, "servidor", ca, "localhost")
+	cfg := Config{CertFile: filepath.Join(dir, "servidor.pem"), KeyFile: filepath.Join(dir, "servidor-key.pem")}
+	r, err := newReloader(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Fuerza la revisión de los archivos en la próxima lectura
+	touch := func() {
+		later := time.Now().Add(time.Minute)
+		for _, file := range []string{cfg.CertFile, cfg.KeyFile} {
+			os.Chtimes(file, later, later)
+		}
+		r.mu.Lock()
+		r.checked = time.Time{}
+		r.mu.Unlock()
+	}
+	serial := func() *big.Int {
+		cert, _ := r.current()
+		parsed, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed.SerialNumber
+	}
+
+	tests := []struct {
+		name   string
+		change func() *testCert // Nuevo certificado esperado (nil = se mantiene el anterior)
+	}{
+		{"certificado nuevo", func() *testCert { return writeCert(t, dir, "servidor", ca, "localhost") }},
+		{"certificado inválido", func() *testCert {
+			os.WriteFile(cfg.CertFile, []byte("basura"), 0600)
+			return nil
+		}},
+	}
+	want := first
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if next := tt.change(); next != nil {
+				want = next
+			}
+			touch()
+			if got := serial(); got.Cmp(want.cert.SerialNumber) != 0 {
+				t.Errorf("certificado con serie %v, se esperaba %v", got, want.cert.SerialNumber)
+			}
+		})
+	}
+}
+
+func TestParseNames(t *testing.T) {
+	tests := []struct {
+		list string
+		want []string
+	}{
+		{"", nil},
+		{"cliente", []string{"cliente"}},
+		{" cliente , admin,,", []string{"cliente", "admin"}},
+	}
+	for _, tt := range tests {
+		if got := ParseNames(tt.list); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseNames(%q) = %v, se esperaba %v", tt.list, got, tt.want)
+		}
+	}
+}
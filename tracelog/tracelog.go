@@ -0,0 +1,110 @@
+// Package tracelog lee y escribe trazas de solicitudes en formato JSON Lines
+// para grabar tráfico y reproducirlo luego con el cliente.
+package tracelog
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// Una solicitud grabada
+type Record struct {
+	Time     time.Time         `json:"ts"`
+	Method   string            `json:"method"`
+	WorkID   int32             `json:"work_id"`
+	WorkType string            `json:"work_type,omitempty"`
+	Tenant   string            `json:"tenant,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Claves de metadata que nunca se graban: credenciales y claves de afinidad,
+// que permiten suplantar la sesión de otro cliente
+var sensitiveKeys = map[string]bool{
+	"authorization":  true,
+	"x-api-key":      true,
+	"cookie":         true,
+	"x-session":      true,
+	"x-affinity-key": true,
+}
+
+// Copia la metadata grabable: sin credenciales ni claves internas de gRPC
+func FilterMetadata(md metadata.MD) map[string]string {
+	out := make(map[string]string)
+	for key, values := range md {
+		if len(values) == 0 || sensitiveKeys[key] || strings.HasPrefix(key, "grpc-") || strings.HasPrefix(key, ":") ||
+			key == "user-agent" || key == "content-type" {
+			continue
+		}
+		out[key] = values[0]
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// Escritor de trazas seguro para uso concurrente
+type Writer struct {
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+// Abre (o crea) el archivo de traza para agregar registros
+func Create(filename string) (*Writer, error) {
+	file, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("error al abrir el archivo de traza: %v", err)
+	}
+	return &Writer{file: file, enc: json.NewEncoder(file)}, nil
+}
+
+// Agrega un registro a la traza
+func (w *Writer) Write(r Record) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.enc.Encode(r)
+}
+
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.file.Close()
+}
+
+// Lee una traza completa ordenada por tiempo
+func ReadFile(filename string) ([]Record, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error al abrir el archivo de traza: %v", err)
+	}
+	defer file.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var r Record
+		if err := json.Unmarshal([]byte(text), &r); err != nil {
+			return nil, fmt.Errorf("línea %d de la traza inválida: %v", line, err)
+		}
+		records = append(records, r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error al leer el archivo de traza: %v", err)
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Time.Before(records[j].Time) })
+	return records, nil
+}
@@ -0,0 +1,31 @@
+package tracelog
+
+import (
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+)
+
+func TestFilterMetadata(t *testing.T) {
+	tests := []struct {
+		key  string
+		kept bool
+	}{
+		{"x-tenant", true},
+		{"authorization", false},
+		{"x-api-key", false},
+		{"cookie", false},
+		{"x-session", false},
+		{"x-affinity-key", false},
+		{"grpc-timeout", false},
+		{"user-agent", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			_, kept := FilterMetadata(metadata.Pairs(tt.key, "valor"))[tt.key]
+			if kept != tt.kept {
+				t.Errorf("grabada = %v, se esperaba %v", kept, tt.kept)
+			}
+		})
+	}
+}